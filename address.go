@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/cnnrmnn/godipper/chilis"
@@ -33,6 +34,9 @@ func (as addressService) findByID(id int) (*Address, error) {
 	var a Address
 	err := as.db.QueryRow(q, id).
 		Scan(&a.ID, &a.UserID, &a.Street, &a.Unit, &a.City, &a.State, &a.Zip, &a.Notes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError{"address"}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding address by ID: %v", err)
 	}
@@ -0,0 +1,202 @@
+package chilis
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// A CartLine is a line item in a Session's cart.
+type CartLine struct {
+	// ID identifies the line when removing it from the cart.
+	ID         string          `json:"id"`
+	Name       string          `json:"name"`
+	Quantity   int             `json:"quantity"`
+	Price      Money           `json:"price"`
+	Selections []CartSelection `json:"selections"`
+}
+
+// A CartSelection is an item selected in a cart line and the extras selected
+// with it.
+type CartSelection struct {
+	Name   string   `json:"name"`
+	Extras []string `json:"extras"`
+}
+
+// extraSuffix is appended to the names of extras in a cart line's choices.
+const extraSuffix = " - Extra"
+
+// Matches reports whether the line is the given Combo: whether its selections
+// are the Combo's items, in order, with the same extras.
+func (l CartLine) Matches(c Combo) bool {
+	its := c.ItemValues()
+	if len(its) != len(l.Selections) {
+		return false
+	}
+	for i, it := range its {
+		sel := l.Selections[i]
+		if sel.Name != it.String() || !sameValues(sel.Extras, it.ExtraValues()) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesItem reports whether the line is the given quantity of the menu item
+// with the given name with the options with the given names selected.
+func (l CartLine) MatchesItem(name string, qty int, options []string) bool {
+	if l.Name != name || l.Quantity != qty {
+		return false
+	}
+	var sels []string
+	for _, sel := range l.Selections {
+		sels = append(sels, sel.Name)
+	}
+	return sameValues(sels, options)
+}
+
+// sameValues reports whether the given slices contain the same values,
+// ignoring order.
+func sameValues(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	count := make(map[string]int)
+	for _, v := range a {
+		count[v]++
+	}
+	for _, v := range b {
+		count[v]--
+		if count[v] < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// CartContents returns the line items in the Session's cart.
+func (s *Session) CartContents(ctx context.Context) ([]CartLine, error) {
+	doc, err := s.parsePage(ctx, "/cart")
+	if err != nil {
+		return nil, fmt.Errorf("fetching cart: %v", err)
+	}
+	lines, err := parseCartLines(doc)
+	return lines, s.snapshot(err)
+}
+
+// RemoveLine removes the line item with the given ID from the Session's cart.
+// Like the cart page's line items, the removal form that it posts hasn't been
+// captured from Chili's.
+func (s *Session) RemoveLine(ctx context.Context, id string) error {
+	doc, err := s.parsePage(ctx, "/cart")
+	if err != nil {
+		return fmt.Errorf("fetching cart: %v", err)
+	}
+	csrf, err := parseCSRFToken(doc)
+	if err != nil {
+		return s.snapshot(fmt.Errorf("building cart removal request: %v", err))
+	}
+	form := url.Values{}
+	form.Add("_csrf", csrf)
+	form.Add("lineItemId", id)
+	resp, err := s.postForm(ctx, "/cart/remove", form)
+	if err != nil {
+		return fmt.Errorf("posting cart removal request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("removing cart line %s: %s", id, resp.Status)
+	}
+	return nil
+}
+
+// ClearCart removes every line item from the Session's cart.
+func (s *Session) ClearCart(ctx context.Context) error {
+	lines, err := s.CartContents(ctx)
+	if err != nil {
+		return fmt.Errorf("clearing cart: %w", err)
+	}
+	for _, l := range lines {
+		err = s.RemoveLine(ctx, l.ID)
+		if err != nil {
+			return fmt.Errorf("clearing cart: %v", err)
+		}
+	}
+	return nil
+}
+
+// parseCartLines parses and returns the line items on the given cart page. An
+// empty cart has no line items. The markup of the line items hasn't been
+// captured from Chili's, so it's only trusted when it agrees with the header's
+// cart button, which has been: ErrCartUnrecognized is returned if there's no
+// cart button, if it says that the cart has items but no line items are found,
+// or if a line item can't be parsed.
+func parseCartLines(doc *html.Node) ([]CartLine, error) {
+	has, err := selHeaderCart.selectAttr(doc, "data-cart-has-items")
+	if err != nil {
+		return nil, ErrCartUnrecognized
+	}
+	if has != "true" {
+		return nil, nil
+	}
+	nodes, err := selCartLines.find(doc)
+	if err != nil {
+		return nil, ErrCartUnrecognized
+	}
+	var lines []CartLine
+	for _, node := range nodes {
+		l, err := parseCartLine(node)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrCartUnrecognized, len(lines)+1, err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// parseCartLine parses and returns a line item given its node on the cart page.
+func parseCartLine(node *html.Node) (CartLine, error) {
+	var l CartLine
+	var err error
+	l.ID, err = selCartLineID.selectAttr(node, "value")
+	if err != nil {
+		return l, err
+	}
+	name, err := selCartLineName.innerText(node)
+	if err != nil {
+		return l, err
+	}
+	l.Name = strings.TrimSpace(name)
+	qty, err := selCartLineQty.innerText(node)
+	if err != nil {
+		return l, err
+	}
+	l.Quantity, err = strconv.Atoi(strings.TrimSpace(qty))
+	if err != nil {
+		return l, fmt.Errorf("parsing quantity: %v", err)
+	}
+	l.Price, err = parsePrice(node, selCartLinePrice)
+	if err != nil {
+		return l, err
+	}
+	choices, _ := selCartLineChoice.find(node)
+	for _, li := range choices {
+		choice := strings.TrimSpace(htmlquery.InnerText(li))
+		if strings.HasSuffix(choice, extraSuffix) {
+			if len(l.Selections) == 0 {
+				return l, fmt.Errorf("extra %s has no selection", choice)
+			}
+			sel := &l.Selections[len(l.Selections)-1]
+			sel.Extras = append(sel.Extras, strings.TrimSuffix(choice, extraSuffix))
+			continue
+		}
+		l.Selections = append(l.Selections, CartSelection{Name: choice})
+	}
+	return l, nil
+}
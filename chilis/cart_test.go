@@ -0,0 +1,103 @@
+package chilis
+
+import (
+	"errors"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+)
+
+func TestParseCartLines(t *testing.T) {
+	// The cart page fixture is synthetic. Its line items are what
+	// parseCartLines expects rather than what Chili's has been seen to serve.
+	doc, err := htmlquery.LoadDoc("testdata/cart_synthetic1.html")
+	if err != nil {
+		log.Fatalf("loading HTML document: %v", err)
+	}
+	want := []CartLine{
+		{
+			ID:       "2209411",
+			Name:     "Triple Dipper™",
+			Quantity: 1,
+			Price:    1319,
+			Selections: []CartSelection{
+				{Name: "Big Mouth® Bites", Extras: []string{"Ranch Dressing"}},
+				{Name: "Fried Pickles"},
+				{Name: "Boneless House BBQ Wings"},
+			},
+		},
+		{
+			ID:       "2209412",
+			Name:     "Triple Dipper™",
+			Quantity: 2,
+			Price:    2638,
+			Selections: []CartSelection{
+				{Name: "Awesome Blossom Petals", Extras: []string{"Ranch Dressing"}},
+				{Name: "Big Mouth® Bites"},
+				{Name: "Boneless Buffalo Wings", Extras: []string{"Bleu Cheese Dressing"}},
+			},
+		},
+	}
+	got, err := parseCartLines(doc)
+	if err != nil {
+		t.Fatalf("parseCartLines: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseCartLines = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseCartLinesEmpty(t *testing.T) {
+	doc, err := htmlquery.LoadDoc("testdata/dipper1.html")
+	if err != nil {
+		log.Fatalf("loading HTML document: %v", err)
+	}
+	got, err := parseCartLines(doc)
+	if err != nil {
+		t.Fatalf("parseCartLines: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("len(lines) = %d, want 0", len(got))
+	}
+}
+
+func TestParseCartLinesUnrecognized(t *testing.T) {
+	// The checkout page says that the cart has items, but it doesn't list
+	// them.
+	doc, err := htmlquery.LoadDoc("testdata/checkout1.html")
+	if err != nil {
+		log.Fatalf("loading HTML document: %v", err)
+	}
+	_, err = parseCartLines(doc)
+	if !errors.Is(err, ErrCartUnrecognized) {
+		t.Errorf("err = %v, want ErrCartUnrecognized", err)
+	}
+}
+
+func TestCartLineMatches(t *testing.T) {
+	line := CartLine{Selections: []CartSelection{
+		{Name: "Awesome Blossom Petals", Extras: []string{"Ranch Dressing"}},
+		{Name: "Big Mouth® Bites"},
+		{Name: "Boneless Buffalo Wings", Extras: []string{"Bleu Cheese Dressing"}},
+	}}
+	if !line.Matches(testTripleDipper) {
+		t.Errorf("line doesn't match %v", testTripleDipper)
+	}
+	other := testDipper{
+		testItem{"Awesome Blossom Petals", nil},
+		testItem{"Big Mouth® Bites", nil},
+		testItem{"Boneless Buffalo Wings", []string{"Bleu Cheese Dressing"}},
+	}
+	if line.Matches(other) {
+		t.Errorf("line matches %v, want no match", other)
+	}
+}
+
+func FuzzParseCartLines(f *testing.F) {
+	addSeeds(f, "cart*.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseCartLines(parseSeed(t, b))
+	})
+}
@@ -0,0 +1,219 @@
+package chilis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// A Cassette is an http.RoundTripper that either records every request made
+// through it and its response, or replays recorded responses without making
+// any requests. Requests are matched to recorded ones by their method, path,
+// cookies, and form fields (including the query string), ignoring the order
+// of values. Each recorded response is replayed once, in the order recorded.
+//
+// Cassettes contain everything that was sent to and received from Chili's, so
+// they shouldn't be recorded with real payment details.
+type Cassette struct {
+	// rt makes requests while recording. It's nil while replaying.
+	rt http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []interaction
+	used         []bool
+}
+
+// An interaction is a recorded request and its response.
+type interaction struct {
+	Request  cassetteRequest  `json:"request"`
+	Response cassetteResponse `json:"response"`
+}
+
+// A cassetteRequest is the normalized form of a request that's used to match
+// requests to recorded ones.
+type cassetteRequest struct {
+	Method  string     `json:"method"`
+	Path    string     `json:"path"`
+	Cookies []string   `json:"cookies,omitempty"`
+	Form    url.Values `json:"form,omitempty"`
+}
+
+// A cassetteResponse is a recorded response.
+type cassetteResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// NewCassette returns a pointer to a new Cassette that records requests made
+// using the given RoundTripper. If it's nil, http.DefaultTransport is used.
+func NewCassette(rt http.RoundTripper) *Cassette {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &Cassette{rt: rt}
+}
+
+// LoadCassette returns a pointer to a Cassette that replays the recording saved
+// at the given path.
+func LoadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading cassette: %v", err)
+	}
+	var c Cassette
+	err = json.Unmarshal(b, &c.interactions)
+	if err != nil {
+		return nil, fmt.Errorf("loading cassette %s: %v", path, err)
+	}
+	c.used = make([]bool, len(c.interactions))
+	return &c, nil
+}
+
+// Save writes the Cassette's recording to the given path.
+func (c *Cassette) Save(path string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "\t")
+	err := enc.Encode(c.interactions)
+	if err != nil {
+		return fmt.Errorf("saving cassette: %v", err)
+	}
+	err = os.WriteFile(path, buf.Bytes(), 0644)
+	if err != nil {
+		return fmt.Errorf("saving cassette: %v", err)
+	}
+	return nil
+}
+
+// Unused returns the number of recorded responses that haven't been replayed.
+func (c *Cassette) Unused() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, u := range c.used {
+		if !u {
+			n++
+		}
+	}
+	return n
+}
+
+// RoundTrip implements http.RoundTripper.
+func (c *Cassette) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	creq := newCassetteRequest(req, body)
+	if c.rt == nil {
+		return c.replay(req, creq)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := c.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	rbody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction{
+		Request: creq,
+		Response: cassetteResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header.Clone(),
+			Body:       string(rbody),
+		},
+	})
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+	resp.Body = io.NopCloser(bytes.NewReader(rbody))
+	return resp, nil
+}
+
+// replay returns the first recorded response that hasn't been replayed yet to
+// a request that matches the given one.
+func (c *Cassette) replay(req *http.Request, creq cassetteRequest) (*http.Response, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, in := range c.interactions {
+		if c.used[i] || !in.Request.matches(creq) {
+			continue
+		}
+		c.used[i] = true
+		r := in.Response
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+			StatusCode:    r.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        r.Header.Clone(),
+			Body:          io.NopCloser(strings.NewReader(r.Body)),
+			ContentLength: int64(len(r.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette: no recorded response to %s %s with form %s",
+		creq.Method, creq.Path, creq.Form.Encode())
+}
+
+// newCassetteRequest returns the normalized form of the given request with the
+// given body.
+func newCassetteRequest(req *http.Request, body []byte) cassetteRequest {
+	form := req.URL.Query()
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		posted, err := url.ParseQuery(string(body))
+		if err == nil {
+			for k, vs := range posted {
+				form[k] = append(form[k], vs...)
+			}
+		}
+	}
+	for _, vs := range form {
+		sort.Strings(vs)
+	}
+
+	var cookies []string
+	for _, cook := range req.Cookies() {
+		cookies = append(cookies, cook.Name+"="+cook.Value)
+	}
+	sort.Strings(cookies)
+
+	path := req.URL.Path
+	if path == "" {
+		path = "/"
+	}
+	return cassetteRequest{req.Method, path, cookies, form}
+}
+
+// matches reports whether the given request matches the recorded one.
+func (r cassetteRequest) matches(other cassetteRequest) bool {
+	if r.Method != other.Method || r.Path != other.Path {
+		return false
+	}
+	if strings.Join(r.Cookies, "; ") != strings.Join(other.Cookies, "; ") {
+		return false
+	}
+	return r.Form.Encode() == other.Form.Encode()
+}
@@ -0,0 +1,551 @@
+// Package chilistest provides a fake Chili's website for testing code that
+// uses package chilis.
+//
+// The fake serves the HTML and JSON fixtures that package chilis' tests parse
+// (dipper, checkout, estimate, location, and confirmation pages), issues
+// SESSION cookies, validates CSRF tokens and form posts, and keeps a
+// server-side cart for every session so that tests can inspect what a client
+// actually submitted.
+//
+// The cart page and its /cart/remove endpoint are synthetic. Chili's cart page
+// hasn't been captured, so the fake lists a session's cart using the markup
+// that package chilis parses, and tests that read or edit the cart only check
+// package chilis against its own assumptions.
+package chilistest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"html"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Fixtures names the fixture files, relative to the Server's fixture
+// directory, that are served for each page.
+type Fixtures struct {
+	Locations    string
+	Dipper       string
+	Checkout     string
+	Estimate     string
+	Confirmation string
+	// Cart, if set, is served as the cart page instead of the synthetic
+	// page listing the session's cart.
+	Cart string
+}
+
+// DefaultFixtures are fixtures from a single Chili's location that can be
+// used together to complete an order.
+var DefaultFixtures = Fixtures{
+	Locations:    "location1.html",
+	Dipper:       "dipper1.html",
+	Checkout:     "checkout1.html",
+	Estimate:     "estimate1.json",
+	Confirmation: "confirmation.html",
+}
+
+// A Session is the server-side state of a Chili's session.
+type Session struct {
+	ID         string
+	CSRF       string
+	LocationID string
+	Cart       []Line
+	Checkout   url.Values
+	Payment    url.Values
+}
+
+// A Line is a line item in a session's cart.
+type Line struct {
+	ID string
+	// Path is the path of the menu page that the line was added from.
+	Path string
+	// SelectedIDs are the IDs of the options selected when the line was
+	// added to the cart.
+	SelectedIDs []string
+	Quantity    int
+}
+
+// A Server is a fake Chili's website. Fixtures and Reject may be changed
+// between requests, but not concurrently with them.
+type Server struct {
+	*httptest.Server
+	Fixtures Fixtures
+	// Reject, if set, is called with the path and form of every checkout and
+	// payment request. If it returns a non-empty message, the form is
+	// rejected: its page is served again showing the message on the named
+	// field or, if field is empty, on the whole form.
+	Reject func(path string, form url.Values) (field, message string)
+	// MenuItems maps the paths of menu item pages, other than the triple
+	// dipper's, to the names of their items. The pages are served using the
+	// dipper fixture, and items are added to the cart from them like triple
+	// dippers are.
+	MenuItems map[string]string
+
+	dir      string
+	mu       sync.Mutex
+	sessions map[string]*Session
+	lines    int
+}
+
+// NewServer starts and returns a new Server that serves fixtures from the
+// given directory. The caller should call Close when finished.
+func NewServer(dir string) *Server {
+	s := &Server{
+		Fixtures: DefaultFixtures,
+		dir:      dir,
+		sessions: make(map[string]*Session),
+	}
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Session returns a copy of the state of the session with the given ID and
+// true, or false if there is no such session.
+func (s *Server) Session(id string) (Session, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return Session{}, false
+	}
+	cp := *sess
+	cp.Cart = append([]Line(nil), sess.Cart...)
+	return cp, true
+}
+
+// Expire forgets the session with the given ID, like Chili's does some time
+// after a session was last used. Later requests with its SESSION cookie are
+// given a new, empty session.
+func (s *Server) Expire(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	sess := s.session(w, r)
+	if r.Method == http.MethodPost {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if r.PostForm.Get("_csrf") != sess.CSRF {
+			http.Error(w, "invalid CSRF token", http.StatusForbidden)
+			return
+		}
+	}
+
+	switch route := r.Method + " " + r.URL.Path; route {
+	case "GET /":
+		fmt.Fprint(w, "<html><body></body></html>")
+	case "GET /locations/results":
+		s.serveFixture(w, sess, s.Fixtures.Locations)
+	case "GET /order":
+		s.mu.Lock()
+		sess.LocationID = r.URL.Query().Get("rid")
+		s.mu.Unlock()
+		fmt.Fprint(w, "<html><body></body></html>")
+	case "GET /menu/appetizers/triple-dipper":
+		s.serveFixture(w, sess, s.Fixtures.Dipper)
+	case "POST /menu/appetizers/triple-dipper":
+		s.cart(w, r, sess, true)
+	case "GET /cart":
+		s.serveCart(w, sess)
+	case "POST /cart/remove":
+		s.removeLine(w, r, sess)
+	case "GET /order/pickup":
+		if s.emptyCart(w, r, sess) {
+			return
+		}
+		s.serveFixture(w, sess, s.Fixtures.Checkout)
+	case "POST /order/pickup":
+		s.checkout(w, r, sess)
+	case "POST /order/delivery/estimate":
+		s.serveFixture(w, sess, s.Fixtures.Estimate)
+	case "GET /order/payment":
+		if s.emptyCart(w, r, sess) {
+			return
+		}
+		fmt.Fprintf(w, paymentPage, sess.CSRF)
+	case "POST /order/payment":
+		s.pay(w, r, sess)
+	default:
+		if _, ok := s.MenuItems[r.URL.Path]; !ok {
+			http.NotFound(w, r)
+		} else if r.Method == http.MethodPost {
+			s.cart(w, r, sess, false)
+		} else {
+			s.serveFixture(w, sess, s.Fixtures.Dipper)
+		}
+	}
+}
+
+// session returns the session identified by the request's SESSION cookie.
+// If the request has no valid SESSION cookie, a session is created and its
+// cookie is set on the response.
+func (s *Server) session(w http.ResponseWriter, r *http.Request) *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cook, err := r.Cookie("SESSION"); err == nil {
+		if sess, ok := s.sessions[cook.Value]; ok {
+			return sess
+		}
+	}
+	sess := &Session{ID: uuid(), CSRF: uuid()}
+	s.sessions[sess.ID] = sess
+	http.SetCookie(w, &http.Cookie{
+		Name:     "SESSION",
+		Value:    sess.ID,
+		Path:     "/",
+		HttpOnly: true,
+	})
+	return sess
+}
+
+// emptyCart redirects to the home page and returns true if the session's cart
+// is empty, like Chili's does for pages that need a cart.
+func (s *Server) emptyCart(w http.ResponseWriter, r *http.Request, sess *Session) bool {
+	s.mu.Lock()
+	empty := len(sess.Cart) == 0
+	s.mu.Unlock()
+	if empty {
+		http.Redirect(w, r, "/", http.StatusFound)
+	}
+	return empty
+}
+
+// csrfPattern matches the CSRF token input in a Chili's page.
+var csrfPattern = regexp.MustCompile(`(name="_csrf" value=")[^"]*(")`)
+
+// optionPattern matches the value of every option in a Chili's page.
+var optionPattern = regexp.MustCompile(`<option value="([^"]+)"`)
+
+// namedOptionPattern matches the value, remaining attributes, and text of
+// every option in a Chili's page.
+var namedOptionPattern = regexp.MustCompile(`<option value="([^"]+)"([^>]*)>([^<]*)`)
+
+// fixture reads and returns the contents of the fixture with the given name.
+func (s *Server) fixture(name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(s.dir, name))
+}
+
+// cartItemsPattern matches the attribute of the header's cart button that
+// tells whether the cart has items in it.
+var cartItemsPattern = regexp.MustCompile(`data-cart-has-items="[^"]*"`)
+
+// page returns the fixture with the given name with its CSRF token, if any,
+// replaced by the session's token, and its header showing whether the
+// session's cart has items in it.
+func (s *Server) page(sess *Session, name string) ([]byte, error) {
+	b, err := s.fixture(name)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	has := len(sess.Cart) > 0
+	s.mu.Unlock()
+	b = csrfPattern.ReplaceAll(b, []byte("${1}"+sess.CSRF+"${2}"))
+	return cartItemsPattern.ReplaceAll(b, []byte(fmt.Sprintf(`data-cart-has-items="%t"`, has))), nil
+}
+
+// bodyPattern matches the opening body tag of a page.
+var bodyPattern = regexp.MustCompile(`<body[^>]*>`)
+
+// rejected writes the given page showing an error and returns true if Reject
+// rejects the request's form.
+func (s *Server) rejected(w http.ResponseWriter, r *http.Request, page []byte) bool {
+	if s.Reject == nil {
+		return false
+	}
+	field, msg := s.Reject(r.URL.Path, r.PostForm)
+	if msg == "" {
+		return false
+	}
+	var markup string
+	if field != "" {
+		markup = fmt.Sprintf(`<span class="field-error" data-field="%s">%s</span>`,
+			html.EscapeString(field), html.EscapeString(msg))
+	} else {
+		markup = fmt.Sprintf(`<div class="alert alert-error form-errors"><p class="error-message">%s</p></div>`,
+			html.EscapeString(msg))
+	}
+	page = bodyPattern.ReplaceAllFunc(page, func(tag []byte) []byte {
+		return append(tag, markup...)
+	})
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(page)
+	return true
+}
+
+// serveFixture writes the fixture with the given name with its CSRF token, if
+// any, replaced by the session's token.
+func (s *Server) serveFixture(w http.ResponseWriter, sess *Session, name string) {
+	b, err := s.page(sess, name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if filepath.Ext(name) == ".json" {
+		w.Header().Set("Content-Type", "application/json")
+	} else {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+	w.Write(b)
+}
+
+// cart adds the posted quantity of the posted selected IDs to the session's
+// cart if every one of them is an option on the dipper page. Unless required
+// is false, at least one ID must be selected.
+func (s *Server) cart(w http.ResponseWriter, r *http.Request, sess *Session, required bool) {
+	b, err := s.fixture(s.Fixtures.Dipper)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	options := make(map[string]bool)
+	for _, m := range optionPattern.FindAllSubmatch(b, -1) {
+		options[string(m[1])] = true
+	}
+
+	ids := r.PostForm["selectedIds"]
+	resp := make(map[string]interface{})
+	if len(ids) == 0 && required {
+		resp["error"] = "no items selected"
+	}
+	qty := 1
+	if raw := r.PostForm.Get("quantity"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			resp["error"] = "invalid quantity " + raw
+		}
+		qty = n
+	}
+	for _, id := range ids {
+		if !options[id] {
+			resp["error"] = "invalid item " + id
+			break
+		}
+	}
+	s.mu.Lock()
+	if _, ok := resp["error"]; !ok {
+		s.lines++
+		sess.Cart = append(sess.Cart, Line{
+			ID:          strconv.Itoa(s.lines),
+			Path:        r.URL.Path,
+			SelectedIDs: ids,
+			Quantity:    qty,
+		})
+	}
+	resp["cartCount"] = len(sess.Cart)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// linePrice is the price of every line in a cart page.
+const linePrice = "$13.19"
+
+// serveCart writes a cart page listing the session's cart. Unless the Cart
+// fixture is set, the page is synthetic.
+func (s *Server) serveCart(w http.ResponseWriter, sess *Session) {
+	if s.Fixtures.Cart != "" {
+		s.serveFixture(w, sess, s.Fixtures.Cart)
+		return
+	}
+	b, err := s.fixture(s.Fixtures.Dipper)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	names := make(map[string]string)
+	for _, m := range namedOptionPattern.FindAllSubmatch(b, -1) {
+		name := html.UnescapeString(string(m[3]))
+		if bytes.Contains(m[2], []byte("data-cost-extra")) {
+			name += " - Extra"
+		}
+		names[string(m[1])] = name
+	}
+
+	type line struct {
+		ID       string
+		Name     string
+		Quantity int
+		Choices  []string
+	}
+	data := struct {
+		CSRF  string
+		Price string
+		Lines []line
+	}{CSRF: sess.CSRF, Price: linePrice}
+	s.mu.Lock()
+	for _, l := range sess.Cart {
+		cl := line{ID: l.ID, Name: "Triple Dipper™", Quantity: l.Quantity}
+		if name, ok := s.MenuItems[l.Path]; ok {
+			cl.Name = name
+		}
+		for _, id := range l.SelectedIDs {
+			cl.Choices = append(cl.Choices, names[id])
+		}
+		data.Lines = append(data.Lines, cl)
+	}
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	cartPage.Execute(w, data)
+}
+
+// removeLine removes the posted line from the session's cart and redirects to
+// the cart page. Its route and form are synthetic, like the cart page.
+func (s *Server) removeLine(w http.ResponseWriter, r *http.Request, sess *Session) {
+	id := r.PostForm.Get("lineItemId")
+	s.mu.Lock()
+	found := false
+	for i, l := range sess.Cart {
+		if l.ID == id {
+			sess.Cart = append(sess.Cart[:i:i], sess.Cart[i+1:]...)
+			found = true
+			break
+		}
+	}
+	s.mu.Unlock()
+	if !found {
+		http.Error(w, "unknown line item "+id, http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/cart", http.StatusFound)
+}
+
+// checkoutFields are the fields that must be set in a checkout form.
+var checkoutFields = []string{
+	"orderMode", "firstName", "lastName", "contactPhone", "email",
+	"pickupDate", "pickupTime", "inAuthData.transactionId",
+}
+
+// deliveryFields are the fields that must also be set in a checkout form for
+// a delivery order.
+var deliveryFields = []string{"deliveryAddress", "deliveryDate", "deliveryTime"}
+
+// checkout records the posted checkout form and redirects to the payment page
+// unless Reject rejects it.
+func (s *Server) checkout(w http.ResponseWriter, r *http.Request, sess *Session) {
+	if !required(w, r.PostForm, checkoutFields) {
+		return
+	}
+	switch r.PostForm.Get("orderMode") {
+	case "delivery":
+		if !required(w, r.PostForm, deliveryFields) {
+			return
+		}
+	case "pickup":
+	default:
+		http.Error(w, "invalid orderMode", http.StatusBadRequest)
+		return
+	}
+	page, err := s.page(sess, s.Fixtures.Checkout)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s.rejected(w, r, page) {
+		return
+	}
+	s.mu.Lock()
+	empty := len(sess.Cart) == 0
+	if !empty {
+		sess.Checkout = r.PostForm
+	}
+	s.mu.Unlock()
+	if empty {
+		http.Error(w, "cart is empty", http.StatusBadRequest)
+		return
+	}
+	http.Redirect(w, r, "/order/payment", http.StatusFound)
+}
+
+// paymentFields are the fields that must be set in a payment form.
+var paymentFields = []string{
+	"paymentMethod", "orderMode", "cardType", "cardNumber", "cvv", "expirationMonth",
+	"expirationYear", "nameOnCard", "zipcode",
+}
+
+// pay records the posted payment form and writes the confirmation page unless
+// Reject rejects it.
+func (s *Server) pay(w http.ResponseWriter, r *http.Request, sess *Session) {
+	if !required(w, r.PostForm, paymentFields) {
+		return
+	}
+	if s.rejected(w, r, []byte(fmt.Sprintf(paymentPage, sess.CSRF))) {
+		return
+	}
+	s.mu.Lock()
+	checkout := sess.Checkout
+	ok := checkout != nil && checkout.Get("orderMode") == r.PostForm.Get("orderMode")
+	if ok {
+		sess.Payment = r.PostForm
+	}
+	s.mu.Unlock()
+	if checkout == nil {
+		http.Error(w, "check out before paying", http.StatusBadRequest)
+		return
+	}
+	if !ok {
+		http.Error(w, "orderMode doesn't match checkout", http.StatusBadRequest)
+		return
+	}
+	s.serveFixture(w, sess, s.Fixtures.Confirmation)
+}
+
+// required writes a 400 response and returns false if any of the given fields
+// are missing from the form.
+func required(w http.ResponseWriter, form url.Values, fields []string) bool {
+	for _, f := range fields {
+		if form.Get(f) == "" {
+			http.Error(w, "missing "+f, http.StatusBadRequest)
+			return false
+		}
+	}
+	return true
+}
+
+// paymentPage is the page served in place of Chili's payment page. It only
+// contains what is needed to submit a payment.
+const paymentPage = `<html><body>
+<a id="header-cart" class="cart-btn js-cart-btn" href="/cart" data-cart-has-items="true"></a>
+<form id="payment-form" action="/order/payment" method="post">
+<input type="hidden" name="_csrf" value="%s"/>
+</form>
+</body></html>`
+
+// cartPage is the template of the page served in place of Chili's cart page.
+// Only its header's cart button is Chili's markup. Its line items and their
+// removal forms were written to match what package chilis parses.
+var cartPage = template.Must(template.New("cart").Parse(`<html><body>
+<a id="header-cart" class="cart-btn js-cart-btn" href="/cart" data-cart-has-items="{{if .Lines}}true{{else}}false{{end}}"></a>
+{{range .Lines}}<div class="row item-summary-info cart-line">
+<div class="item-info"><div>{{.Name}}</div></div>
+<div class="qty-info"><div>{{.Quantity}}</div></div>
+<div class="cost-info"><div>{{$.Price}}</div></div>
+<div class="choice-list">{{range .Choices}}<ul><li>{{.}}</li></ul>{{end}}</div>
+<form class="remove-item-form" action="/cart/remove" method="post">
+<input type="hidden" name="_csrf" value="{{$.CSRF}}"/>
+<input type="hidden" name="lineItemId" value="{{.ID}}"/>
+</form>
+</div>
+{{end}}</body></html>`))
+
+// uuid returns a random UUID-formatted string.
+func uuid() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,175 @@
+package chilis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the Chili's website.
+const DefaultBaseURL = "https://www.chilis.com"
+
+// A Client configures how Sessions communicate with Chili's. Its zero value
+// talks to the Chili's website using http.DefaultTransport.
+type Client struct {
+	// BaseURL is the scheme and host that every request is made against. If
+	// it's empty, DefaultBaseURL is used.
+	BaseURL string
+	// Transport makes the HTTP requests. If it's nil, http.DefaultTransport
+	// is used.
+	Transport http.RoundTripper
+	// Timeout limits the time taken by each request. Zero means no timeout.
+	Timeout time.Duration
+	// UserAgent, if set, is sent as the User-Agent header of every request.
+	UserAgent string
+	// MinGap is the minimum time between the start of consecutive requests
+	// made by a Session. A Session always makes one request at a time.
+	MinGap time.Duration
+	// Retries is the number of times that a GET request is retried if it
+	// fails with a transport error or a 5xx response. Other requests, like
+	// the cart and payment POSTs, are never retried.
+	Retries int
+	// Backoff is the time waited before the first retry. It doubles for each
+	// subsequent retry. If it's zero, DefaultBackoff is used.
+	Backoff time.Duration
+	// OnAttempt, if set, is called after every attempt at a request.
+	OnAttempt func(Attempt)
+	// SnapshotDir, if set, is the directory that responses that can't be
+	// parsed are saved to, so that they can be turned into test fixtures. CSRF
+	// tokens, card numbers, and cookie values are redacted from them.
+	SnapshotDir string
+	// MenuTTL is how long the IDs of the items and extras on a location's
+	// triple dipper page are cached for. The cache is shared by every Session
+	// that the Client creates. If it's zero, DefaultMenuTTL is used, and if
+	// it's negative, nothing is cached.
+	MenuTTL time.Duration
+
+	menusOnce sync.Once
+	menus     *menuCache
+}
+
+// DefaultClient is the Client used by StartSession and NewSession.
+var DefaultClient = &Client{}
+
+// baseURL parses and returns the Client's base URL.
+func (c *Client) baseURL() (*url.URL, error) {
+	raw := c.BaseURL
+	if raw == "" {
+		raw = DefaultBaseURL
+	}
+	u, err := url.Parse(strings.TrimSuffix(raw, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %v", err)
+	}
+	return u, nil
+}
+
+// newSession returns a pointer to a new Session, configured according to the
+// Client, that stores cookies in the given jar.
+func (c *Client) newSession(id string, jar http.CookieJar, base *url.URL) *Session {
+	var rt http.RoundTripper = http.DefaultTransport
+	if c.Transport != nil {
+		rt = c.Transport
+	}
+	if c.UserAgent != "" {
+		rt = userAgentTransport{c.UserAgent, rt}
+	}
+	var rec *recorder
+	if c.SnapshotDir != "" {
+		rec = &recorder{rt: rt, dir: c.SnapshotDir}
+		rt = rec
+	}
+	clt := &http.Client{
+		Jar:       jar,
+		Transport: newPacedTransport(rt, c),
+		Timeout:   c.Timeout,
+	}
+	c.menusOnce.Do(func() {
+		c.menus = newMenuCache(c.MenuTTL)
+	})
+	return &Session{ID: id, Client: clt, base: base, rec: rec, menus: c.menus}
+}
+
+// NewSession returns a pointer to a new Session given a session ID.
+func (c *Client) NewSession(id string) (*Session, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %v", err)
+	}
+	cook := http.Cookie{Name: "SESSION", Value: id}
+	jar, err := createSessionJar(base, &cook)
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %v", err)
+	}
+	return c.newSession(id, jar, base), nil
+}
+
+// RestoreSession returns a pointer to the Session exported as the given blob.
+func (c *Client) RestoreSession(blob []byte) (*Session, error) {
+	var es exportedSession
+	err := json.Unmarshal(blob, &es)
+	if err != nil {
+		return nil, fmt.Errorf("restoring session: %v", err)
+	}
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, fmt.Errorf("restoring session: %v", err)
+	}
+	jar, err := createJar()
+	if err != nil {
+		return nil, fmt.Errorf("restoring session: %v", err)
+	}
+	jar.restore(base, es.Cookies)
+	s := c.newSession(es.ID, jar, base)
+	s.LocationID = es.LocationID
+	if s.ID == "" {
+		s.ID, err = sessionID(s.Client, base)
+		if err != nil {
+			return nil, fmt.Errorf("restoring session: %v", err)
+		}
+	}
+	return s, nil
+}
+
+// StartSession returns a pointer to a new Session.
+func (c *Client) StartSession(ctx context.Context) (*Session, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return nil, fmt.Errorf("starting session: %v", err)
+	}
+	jar, err := createJar()
+	if err != nil {
+		return nil, fmt.Errorf("starting session: %v", err)
+	}
+	s := c.newSession("", jar, base)
+	resp, err := s.get(ctx, "/")
+	if err != nil {
+		return nil, fmt.Errorf("starting session: %v", err)
+	}
+	resp.Body.Close()
+
+	s.ID, err = sessionID(s.Client, base)
+	if err != nil {
+		return nil, fmt.Errorf("starting session: %v", err)
+	}
+	return s, nil
+}
+
+// userAgentTransport is an http.RoundTripper that sets the User-Agent header
+// of every request before passing it on to the underlying RoundTripper.
+type userAgentTransport struct {
+	ua string
+	rt http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.ua)
+	return t.rt.RoundTrip(req)
+}
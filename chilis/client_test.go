@@ -0,0 +1,151 @@
+package chilis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClientStartSession(t *testing.T) {
+	ctx := context.Background()
+	ua := "godipper-test"
+	var gotUA string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUA = r.UserAgent()
+		http.SetCookie(w, &http.Cookie{Name: "SESSION", Value: "abc123", Path: "/"})
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL + "/", UserAgent: ua}
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if sess.ID != "abc123" {
+		t.Errorf("ID = %s, want abc123", sess.ID)
+	}
+	if gotUA != ua {
+		t.Errorf("User-Agent = %s, want %s", gotUA, ua)
+	}
+}
+
+func TestClientStartSessionNoCookie(t *testing.T) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	_, err := c.StartSession(ctx)
+	if err == nil {
+		t.Errorf("err = nil, want missing session cookie error")
+	}
+}
+
+func TestClientNewSession(t *testing.T) {
+	ctx := context.Background()
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cook, err := r.Cookie("SESSION")
+		if err == nil {
+			got = cook.Value
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	sess, err := c.NewSession("abc123")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	resp, err := sess.get(ctx, "/order")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if got != "abc123" {
+		t.Errorf("SESSION cookie = %s, want abc123", got)
+	}
+}
+
+func TestSessionContextCanceled(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	c := &Client{BaseURL: srv.URL}
+	sess, err := c.NewSession("abc123")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = sess.DeliverySlots(ctx)
+	if err == nil || ctx.Err() == nil {
+		t.Errorf("err = %v, want error after deadline", err)
+	}
+}
+
+func TestSessionExportRestore(t *testing.T) {
+	ctx := context.Background()
+	got := make(map[string]string)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			http.SetCookie(w, &http.Cookie{Name: "SESSION", Value: "abc123", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "AWSALB", Value: "lb1", MaxAge: 3600})
+			http.SetCookie(w, &http.Cookie{Name: "XSRF", Value: "tok", Path: "/order"})
+			http.SetCookie(w, &http.Cookie{Name: "stale", Value: "x", Path: "/", MaxAge: -1})
+			return
+		}
+		for _, cook := range r.Cookies() {
+			got[cook.Name] = cook.Value
+		}
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL}
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	blob, err := sess.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	restored, err := (&Client{BaseURL: srv.URL}).RestoreSession(blob)
+	if err != nil {
+		t.Fatalf("RestoreSession: %v", err)
+	}
+	if restored.ID != "abc123" {
+		t.Errorf("ID = %s, want abc123", restored.ID)
+	}
+	resp, err := restored.get(ctx, "/order/payment")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	want := map[string]string{"SESSION": "abc123", "AWSALB": "lb1", "XSRF": "tok"}
+	if len(got) != len(want) {
+		t.Errorf("cookies = %v, want %v", got, want)
+	}
+	for name, val := range want {
+		if got[name] != val {
+			t.Errorf("cookie %s = %q, want %q", name, got[name], val)
+		}
+	}
+}
+
+func TestRestoreSessionInvalid(t *testing.T) {
+	_, err := (&Client{}).RestoreSession([]byte("not json"))
+	if err == nil {
+		t.Errorf("err = nil, want invalid blob error")
+	}
+}
@@ -4,10 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
-	"strconv"
 	"time"
 
-	"github.com/antchfx/htmlquery"
 	"golang.org/x/net/html"
 )
 
@@ -23,44 +21,47 @@ type Customer struct {
 	Email     string `json:"email"`
 }
 
+// OrderInfo is the information about an order that Chili's provides at
+// checkout. DeliveryTime is Chili's estimate for ASAP delivery; Slot is the
+// delivery slot that was chosen.
 type OrderInfo struct {
-	Subtotal     float32
-	Tax          float32
-	DeliveryFee  float32
-	ServiceFee   float32
+	Subtotal     Money
+	Tax          Money
+	DeliveryFee  Money
+	ServiceFee   Money
 	DeliveryTime time.Time
+	Slot         DeliverySlot
 }
 
-// checkoutForm adds all of the customer's information to a form map with the default
-// values for every checkout request.
-func checkoutForm(doc *html.Node, c Customer, addr Address) (url.Values, error) {
+// checkoutForm adds all of the customer's information and the slot to a form
+// map with the default values for every checkout request. The address is only
+// added for delivery orders.
+func checkoutForm(doc *html.Node, c Customer, mode OrderMode, addr Address, slot DeliverySlot) (url.Values, error) {
 	form := url.Values{}
 	form.Add("inAuthData.siteKey", "48693e4afc6b92d9")
 	form.Add("inAuthData.collectorURL", "www.cdn-net.com")
 	form.Add("inAuthData.collectorFlags", "34549755")
 	form.Add("inAuthData.enabled", "true")
-	form.Add("deliveryToggle", "on")
-	form.Add("orderMode", "delivery")
+	form.Add("orderMode", string(mode))
 	form.Add("deviceType", "web")
 	form.Add("payment", "online")
 	form.Add("silverwareOptIn", "true")
 	form.Add("smsOptIn", "true")
-	form.Add("deliveryAddress", addr.String())
-	form.Add("deliveryAddress2", addr.Unit)
-	form.Add("deliveryAddlNotes", addr.Notes)
+	if mode == Delivery {
+		form.Add("deliveryToggle", "on")
+		form.Add("deliveryAddress", addr.String())
+		form.Add("deliveryAddress2", addr.Unit)
+		form.Add("deliveryAddlNotes", addr.Notes)
+	}
 	form.Add("firstName", c.FirstName)
 	form.Add("lastName", c.LastName)
 	form.Add("contactPhone", c.Phone)
 	form.Add("email", c.Email)
-	date, time, err := parseASAP(doc)
-	if err != nil {
-		return nil, fmt.Errorf("creating checkout form: %w", err)
-	}
 	// Chili's inexplicably requires all of these fields.
-	form.Add("deliveryDate", date)
-	form.Add("pickupDate", date)
-	form.Add("deliveryTime", time)
-	form.Add("pickupTime", time)
+	form.Add("deliveryDate", slot.Date)
+	form.Add("pickupDate", slot.Date)
+	form.Add("deliveryTime", slot.Time)
+	form.Add("pickupTime", slot.Time)
 	tid, err := parseTransactionID(doc)
 	if err != nil {
 		return nil, fmt.Errorf("creating checkout form: %v", err)
@@ -112,93 +113,71 @@ func validEmail(email string) error {
 	return BadRequestError{"email"}
 }
 
-// parsePrice finds the first HTML element that matches the given XPath query
-// and returns its inner text (starting with $) parsed at a float.
-func parsePrice(node *html.Node, query string) (float32, error) {
-	var f float32
-	elt, err := findOne(node, query)
+// parsePrice finds the first HTML element that the given selector matches and
+// returns its inner text (starting with $) parsed as Money.
+func parsePrice(node *html.Node, sel *selector) (Money, error) {
+	text, err := sel.innerText(node)
 	if err != nil {
-		return f, fmt.Errorf("parsing inner text: %v", err)
+		return 0, err
 	}
-	f64, err := strconv.ParseFloat(htmlquery.InnerText(elt)[1:], 32)
-	if err != nil {
-		return f, fmt.Errorf("parsing inner text as float: %v", err)
-	}
-	return float32(f64), nil
+	return ParseMoney(text)
 }
 
+// parseInfo parses and returns the prices of a delivery order from the checkout
+// page.
 func parseInfo(doc *html.Node) (info OrderInfo, err error) {
-	info.Subtotal, err = parsePrice(doc, classQuery("div", "cost js-subtotal"))
-	if err != nil {
-		return info, fmt.Errorf("parsing subtotal: %v", err)
-	}
-	// XPath query
-	q := "//tr[@id='pickup-tax-payment']/td[2]/div[@class='cost']"
-	info.Tax, err = parsePrice(doc, q)
+	info, err = parseSubtotalTax(doc, selDeliveryTax)
 	if err != nil {
-		return info, fmt.Errorf("parsing tax: %v", err)
+		return info, err
 	}
-	// XPath query
-	q = "//tr[@id='delivery-fee']/td[2]/div[@class='cost']"
-	info.DeliveryFee, err = parsePrice(doc, q)
+	info.DeliveryFee, err = parsePrice(doc, selDeliveryFee)
 	if err != nil {
-		return info, fmt.Errorf("parsing delivery fee: %v", err)
+		return info, fmt.Errorf("parsing delivery fee: %w", err)
 	}
-	// XPath query
-	q = "//tr[@id='service-charge']/td[2]/div[@class='cost']"
-	info.ServiceFee, err = parsePrice(doc, q)
+	info.ServiceFee, err = parsePrice(doc, selServiceCharge)
 	if err != nil {
-		return info, fmt.Errorf("parsing service charge: %v", err)
+		return info, fmt.Errorf("parsing service charge: %w", err)
 	}
 	return info, nil
 }
 
-// parseASAP parses and returns the ASAP values for the date and time fields
-// in the checkout form.
-func parseASAP(doc *html.Node) (string, string, error) {
-	var date, time string
-	q := attrQuery("div", "id", "delivery-time-group")
-	con, err := findOne(doc, q)
-	if err != nil {
-		return date, time, fmt.Errorf("parsing ASAP delivery: %v", err)
-	}
-	// XPath query
-	q = "/div/select[@id='delivery-date']/option"
-	date, err = selectAttr(con, q, "value")
+// parsePickupInfo parses and returns the prices of a pickup order, which has
+// no delivery or service fees, from the checkout page.
+func parsePickupInfo(doc *html.Node) (info OrderInfo, err error) {
+	return parseSubtotalTax(doc, selPickupTax)
+}
+
+// parseSubtotalTax parses and returns the subtotal and the tax from the
+// checkout page. The page has a tax row for each order mode, so tax selects the
+// one to parse.
+func parseSubtotalTax(doc *html.Node, tax *selector) (info OrderInfo, err error) {
+	info.Subtotal, err = parsePrice(doc, selSubtotal)
 	if err != nil {
-		return date, time, ForbiddenError{"location is not currently delivering"}
+		return info, fmt.Errorf("parsing subtotal: %w", err)
 	}
-	// XPath query
-	q = "/div/select[@id='delivery-time']/option"
-	time, err = selectAttr(con, q, "value")
+	info.Tax, err = parsePrice(doc, tax)
 	if err != nil {
-		return date, time, fmt.Errorf("parsing ASAP delivery time: %v", err)
+		return info, fmt.Errorf("parsing tax: %w", err)
 	}
-	return date, time, nil
+	return info, nil
 }
 
 // parseTransactionID returns the transaction ID associated with the checkout
 // form.
 func parseTransactionID(doc *html.Node) (string, error) {
-	var tid string
-	input, err := findOne(doc, attrQuery("input", "id", "transactionId"))
-	if err != nil {
-		return tid, fmt.Errorf("parsing transaction ID: %v", err)
-	}
-	tid = htmlquery.SelectAttr(input, "value")
-	return tid, nil
+	return selTransactionID.selectAttr(doc, "value")
 }
 
 func parseEstimate(body []byte) (time.Time, error) {
 	var t time.Time
-	var decoded interface{}
+	var decoded map[string]interface{}
 	err := json.Unmarshal(body, &decoded)
 	if err != nil {
 		return t, fmt.Errorf("parsing delivery estimate body: %v", err)
 	}
-	tstr, ok := decoded.(map[string]interface{})["delivery_time"].(string)
+	tstr, ok := decoded["delivery_time"].(string)
 	if !ok {
-		return t, ForbiddenError{"address is out of range"}
+		return t, ErrOutOfRange
 	}
 	t, err = time.Parse(time.RFC3339, tstr)
 	if err != nil {
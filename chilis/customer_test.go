@@ -2,9 +2,11 @@ package chilis
 
 import (
 	"errors"
-	"fmt"
+	"log"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/antchfx/htmlquery"
 	"golang.org/x/net/html"
@@ -17,10 +19,15 @@ var checkoutPaths = []string{
 }
 var checkoutDocs []*html.Node
 
-var infoTests = []OrderInfo{
-	{"$13.19", "$0.93", "$3.99", "$3.25", ""},
-	{"$40.47", "$2.43", "$3.99", "$3.25", ""},
-	{"$83.64", "$6.90", "$3.99", "$3.25", ""},
+var infoTests = []struct {
+	subtotal    string
+	tax         string
+	deliveryFee string
+	serviceFee  string
+}{
+	{"$13.19", "$0.93", "$3.99", "$3.25"},
+	{"$40.47", "$2.43", "$3.99", "$3.25"},
+	{"$83.64", "$6.90", "$3.99", "$3.25"},
 }
 
 var asapTests = []struct {
@@ -52,7 +59,7 @@ func init() {
 	for _, path := range checkoutPaths {
 		doc, err := htmlquery.LoadDoc(path)
 		if err != nil {
-			fmt.Errorf("%s: %v", path, err)
+			log.Fatalf("%s: %v", path, err)
 		}
 		checkoutDocs = append(checkoutDocs, doc)
 	}
@@ -65,25 +72,42 @@ func TestParseInfo(t *testing.T) {
 		if err != nil {
 			t.Errorf("%s: %v", path, err)
 		}
-		if info != test {
-			t.Errorf("%s: info = %+v, want %+v", path, info, test)
+		got := [...]string{info.Subtotal.String(), info.Tax.String(),
+			info.DeliveryFee.String(), info.ServiceFee.String()}
+		want := [...]string{test.subtotal, test.tax, test.deliveryFee,
+			test.serviceFee}
+		if got != want {
+			t.Errorf("%s: prices = %v, want %v", path, got, want)
 		}
 	}
 }
 
-func TestParseASAP(t *testing.T) {
-	for n, test := range asapTests {
-		path := checkoutPaths[n]
-		date, time, err := parseASAP(checkoutDocs[n])
-		if err != nil {
-			t.Errorf("%s: %v", path, err)
-		}
-		if date != test.date {
-			t.Errorf("%s: date = %s, want %s", path, date, test.date)
-		}
-		if time != test.time {
-			t.Errorf("%s: time = %s, want %s", path, time, test.time)
-		}
+func TestParseInfoTax(t *testing.T) {
+	b, err := os.ReadFile("testdata/checkout1.html")
+	if err != nil {
+		log.Fatalf("reading HTML document: %v", err)
+	}
+	// Both of the fixture's tax rows are $0.93, so change the delivery row
+	// to tell them apart.
+	row := `<tr id="delivery-tax" class="order-summary-info-row hidden"><td><div class="order-tax-label">Tax</div></td><td><div class="cost">`
+	page := strings.Replace(string(b), row+"$0.93", row+"$1.23", 1)
+	doc, err := htmlquery.Parse(strings.NewReader(page))
+	if err != nil {
+		log.Fatalf("parsing HTML document: %v", err)
+	}
+	info, err := parseInfo(doc)
+	if err != nil {
+		t.Fatalf("parseInfo: %v", err)
+	}
+	if info.Tax != 123 {
+		t.Errorf("delivery tax = %s, want $1.23", info.Tax)
+	}
+	info, err = parsePickupInfo(doc)
+	if err != nil {
+		t.Fatalf("parsePickupInfo: %v", err)
+	}
+	if info.Tax != 93 {
+		t.Errorf("pickup tax = %s, want $0.93", info.Tax)
 	}
 }
 
@@ -107,12 +131,16 @@ func TestParseEstimate(t *testing.T) {
 		if err != nil {
 			t.Errorf("%s: %v", path, err)
 		}
-		time, err := parseEstimate(body)
+		tm, err := parseEstimate(body)
 		if err != nil {
 			t.Errorf("%s: %v", path, err)
 		}
-		if time != test.time {
-			t.Errorf("%s: time = %s, want %s", path, time, test.time)
+		want, err := time.Parse(time.RFC3339, test.time)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if !tm.Equal(want) {
+			t.Errorf("%s: time = %s, want %s", path, tm, test.time)
 		}
 	}
 }
@@ -137,3 +165,19 @@ func TestParseEstimateRange(t *testing.T) {
 		t.Errorf("%s: err = %v, want %s", path, err, reason)
 	}
 }
+
+func FuzzParseInfo(f *testing.F) {
+	addSeeds(f, "checkout*.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseInfo(parseSeed(t, b))
+	})
+}
+
+func FuzzParseEstimate(f *testing.F) {
+	addSeeds(f, "estimate*.json")
+	f.Add([]byte("[]"))
+	f.Add([]byte("null"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseEstimate(b)
+	})
+}
@@ -0,0 +1,77 @@
+package chilis
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// A DeliverySlot is a time that Chili's offers to deliver an order at or have
+// an order ready for pickup at. Date and Time are the values of the checkout
+// form's date and time fields.
+type DeliverySlot struct {
+	Date  string `json:"date"`
+	Time  string `json:"time"`
+	Label string `json:"label"`
+	ASAP  bool   `json:"asap"`
+}
+
+// parseDeliverySlots parses and returns every delivery slot offered by the
+// checkout form, ASAP first.
+func parseDeliverySlots(doc *html.Node) ([]DeliverySlot, error) {
+	return parseSlots(doc, Delivery)
+}
+
+// parseSlots parses and returns every slot offered by the checkout form for
+// the given order mode, ASAP first.
+func parseSlots(doc *html.Node, mode OrderMode) ([]DeliverySlot, error) {
+	con, err := selTimeGroup.findOne(doc, mode)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s slots: %w", mode, err)
+	}
+	dates, err := selSlotDates.find(con, mode)
+	if err != nil {
+		return nil, mode.unavailable()
+	}
+	times, err := selSlotTimes.find(con, mode)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s times: %w", mode, err)
+	}
+
+	var slots []DeliverySlot
+	for _, d := range dates {
+		date := htmlquery.SelectAttr(d, "value")
+		label := htmlquery.InnerText(d)
+		if htmlquery.SelectAttr(d, "data-asap") == "true" {
+			// Chili's expects the first time to be submitted with ASAP.
+			t := htmlquery.SelectAttr(times[0], "value")
+			slots = append(slots, DeliverySlot{date, t, label, true})
+			continue
+		}
+		for _, t := range times {
+			val := htmlquery.SelectAttr(t, "value")
+			if !strings.HasPrefix(val, date+" ") {
+				continue
+			}
+			l := fmt.Sprintf("%s, %s", label, htmlquery.InnerText(t))
+			slots = append(slots, DeliverySlot{date, val, l, false})
+		}
+	}
+	if len(slots) == 0 {
+		return nil, mode.unavailable()
+	}
+	return slots, nil
+}
+
+// findSlot returns the delivery slot with the given time value. If the time is
+// empty, it returns the ASAP slot.
+func findSlot(slots []DeliverySlot, t string) (DeliverySlot, error) {
+	for _, s := range slots {
+		if (t == "" && s.ASAP) || (t != "" && !s.ASAP && s.Time == t) {
+			return s, nil
+		}
+	}
+	return DeliverySlot{}, BadRequestError{"delivery time"}
+}
@@ -0,0 +1,64 @@
+package chilis
+
+import (
+	"errors"
+	"testing"
+)
+
+var slotTests = []struct {
+	n     int
+	later DeliverySlot
+}{
+	{36, DeliverySlot{"20210303", "20210303 14:15", "Later Today, 2:15 PM", false}},
+	{28, DeliverySlot{"20210303", "20210303 15:15", "Later Today, 3:15 PM", false}},
+	{40, DeliverySlot{"20210303", "20210303 12:15", "Later Today, 12:15 PM", false}},
+}
+
+func TestParseDeliverySlots(t *testing.T) {
+	for n, test := range slotTests {
+		path := checkoutPaths[n]
+		slots, err := parseDeliverySlots(checkoutDocs[n])
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if len(slots) != test.n {
+			t.Errorf("%s: len(slots) = %d, want %d", path, len(slots), test.n)
+		}
+		asap := DeliverySlot{asapTests[n].date, asapTests[n].time, "ASAP", true}
+		if slots[0] != asap {
+			t.Errorf("%s: slots[0] = %+v, want %+v", path, slots[0], asap)
+		}
+		if slots[1] != test.later {
+			t.Errorf("%s: slots[1] = %+v, want %+v", path, slots[1], test.later)
+		}
+	}
+}
+
+func TestFindSlot(t *testing.T) {
+	slots, err := parseDeliverySlots(checkoutDocs[0])
+	if err != nil {
+		t.Fatalf("%s: %v", checkoutPaths[0], err)
+	}
+	slot, err := findSlot(slots, "20210303 18:30")
+	if err != nil {
+		t.Errorf("findSlot: %v", err)
+	}
+	if slot.Date != "20210303" || slot.ASAP {
+		t.Errorf("slot = %+v, want scheduled slot on 20210303", slot)
+	}
+
+	_, err = findSlot(slots, "20210304 18:30")
+	var e BadRequestError
+	if !errors.As(err, &e) || e.Field != "delivery time" {
+		t.Errorf("err = %v, want (BadRequestError) invalid delivery time", err)
+	}
+}
+
+func FuzzParseSlots(f *testing.F) {
+	addSeeds(f, "checkout*.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		doc := parseSeed(t, b)
+		parseSlots(doc, Delivery)
+		parseSlots(doc, Pickup)
+	})
+}
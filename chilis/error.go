@@ -1,5 +1,84 @@
 package chilis
 
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrSessionExpired is returned when Chili's no longer recognizes a Session,
+// which happens some time after it was last used. Its cart is gone, so the
+// order has to be built again in a new Session.
+var ErrSessionExpired = errors.New("chilis session expired")
+
+// ErrCartUnrecognized is returned when a Session's cart page doesn't have the
+// structure that its line items are parsed from. That structure hasn't been
+// verified against Chili's, so the cart's contents can't be trusted and it
+// should be rebuilt in a new Session instead.
+var ErrCartUnrecognized = errors.New("chilis cart page not recognized")
+
+// The ForbiddenErrors returned for reasons that callers may want to handle.
+// They can be compared with errors.Is.
+var (
+	ErrOutOfRange      = ForbiddenError{"address is out of range"}
+	ErrNoLocations     = ForbiddenError{"no locations in proximity"}
+	ErrNotTakingOrders = ForbiddenError{"location is not accepting online orders"}
+	ErrNoDelivery      = ForbiddenError{"location doesn't deliver"}
+	ErrNotDelivering   = ForbiddenError{"location is not currently delivering"}
+	ErrNotTakingPickup = ForbiddenError{"location is not currently taking pickup orders"}
+	ErrStoreClosed     = ForbiddenError{"location is closed"}
+	ErrCardDeclined    = ForbiddenError{"card was declined"}
+)
+
+// An ErrorCode is a stable, machine-readable name for a kind of error.
+type ErrorCode string
+
+// The codes of the errors returned by package chilis.
+const (
+	CodeBadRequest     ErrorCode = "BAD_REQUEST"
+	CodeForbidden      ErrorCode = "FORBIDDEN"
+	CodeOutOfRange     ErrorCode = "OUT_OF_RANGE"
+	CodeNoLocations    ErrorCode = "NO_LOCATIONS"
+	CodeNotDelivering  ErrorCode = "NOT_DELIVERING"
+	CodeStoreClosed    ErrorCode = "STORE_CLOSED"
+	CodeCardDeclined   ErrorCode = "CARD_DECLINED"
+	CodeSessionExpired ErrorCode = "SESSION_EXPIRED"
+	CodeUpstream       ErrorCode = "UPSTREAM"
+)
+
+// codes maps errors to their codes.
+var codes = []struct {
+	err  error
+	code ErrorCode
+}{
+	{ErrSessionExpired, CodeSessionExpired},
+	{ErrOutOfRange, CodeOutOfRange},
+	{ErrNoLocations, CodeNoLocations},
+	{ErrNoDelivery, CodeNotDelivering},
+	{ErrNotDelivering, CodeNotDelivering},
+	{ErrNotTakingOrders, CodeStoreClosed},
+	{ErrNotTakingPickup, CodeStoreClosed},
+	{ErrStoreClosed, CodeStoreClosed},
+	{ErrCardDeclined, CodeCardDeclined},
+}
+
+// Code returns the code of the given error returned by package chilis. Errors
+// that aren't one of the package's typed errors are failures to talk to
+// Chili's or to understand its responses, so their code is CodeUpstream.
+func Code(err error) ErrorCode {
+	for _, c := range codes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	if errors.As(err, new(BadRequestError)) {
+		return CodeBadRequest
+	}
+	if errors.As(err, new(ForbiddenError)) {
+		return CodeForbidden
+	}
+	return CodeUpstream
+}
+
 // BadRequestError is analagous to an HTTP 400 response.
 type BadRequestError struct {
 	Field string
@@ -17,3 +96,14 @@ type ForbiddenError struct {
 func (fe ForbiddenError) Error() string {
 	return fe.Reason
 }
+
+// ParseError is returned when none of the queries for a field of a Chili's page
+// find it, which usually means that Chili's has changed its markup.
+type ParseError struct {
+	Page  string
+	Field string
+}
+
+func (pe *ParseError) Error() string {
+	return fmt.Sprintf("parsing %s page: %s not found", pe.Page, pe.Field)
+}
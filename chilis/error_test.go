@@ -0,0 +1,33 @@
+package chilis
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var codeTests = []struct {
+	err  error
+	want ErrorCode
+}{
+	{BadRequestError{"phone"}, CodeBadRequest},
+	{fmt.Errorf("adding TripleDipper to cart: %w", BadRequestError{"item"}), CodeBadRequest},
+	{ForbiddenError{"something else"}, CodeForbidden},
+	{fmt.Errorf("setting location: %w", ErrNoLocations), CodeNoLocations},
+	{fmt.Errorf("fetching delivery estimate: %w", ErrOutOfRange), CodeOutOfRange},
+	{ErrNoDelivery, CodeNotDelivering},
+	{ErrNotDelivering, CodeNotDelivering},
+	{ErrNotTakingOrders, CodeStoreClosed},
+	{ErrStoreClosed, CodeStoreClosed},
+	{ErrCardDeclined, CodeCardDeclined},
+	{fmt.Errorf("fetching payment information: %w", ErrSessionExpired), CodeSessionExpired},
+	{errors.New("fetching HTML at /: connection refused"), CodeUpstream},
+}
+
+func TestCode(t *testing.T) {
+	for _, test := range codeTests {
+		if got := Code(test.err); got != test.want {
+			t.Errorf("Code(%v) = %s, want %s", test.err, got, test.want)
+		}
+	}
+}
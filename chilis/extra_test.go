@@ -5,37 +5,37 @@ import (
 )
 
 var extraTests = []struct {
-	extra Extra
+	extra string
 	iids  []string
 	ids   []string
 }{
 	{
-		0,
+		"Ancho-Chile Ranch Dressing",
 		[]string{"285722848", "999898768", "284423526"},
 		[]string{"285726142", "999901302", "284426814"},
 	},
 	{
-		1,
+		"Avocado-Ranch Dressing",
 		[]string{"285722846", "2267517501", "284423524"},
 		[]string{"285726135", "2267518005", "284426807"},
 	},
 	{
-		2,
+		"Bleu Cheese Dressing",
 		[]string{"1994890269", "1998403657", "1992203060"},
 		[]string{"3226981473", "3226986236", "3226815555"},
 	},
 	{
-		3,
+		"Honey-Mustard Dressing",
 		[]string{"285722847", "999898767", "284423525"},
 		[]string{"285726137", "999901298", "284426809"},
 	},
 	{
-		4,
+		"Original BBQ Sauce",
 		[]string{"285722843", "999898764", "284423521"},
 		[]string{"1070970656", "1071223175", "1314697285"},
 	},
 	{
-		5,
+		"Ranch Dressing",
 		[]string{"285722842", "999898763", "284423520"},
 		[]string{"285726122", "999901286", "284426794"},
 	},
@@ -45,15 +45,19 @@ var extraTests = []struct {
 // dipper<n>.html
 func TestParseIDExtra(t *testing.T) {
 	for n, doc := range dipperDocs {
+		ids, err := parseOptionIDs(doc, TripleDipperProduct)
+		if err != nil {
+			t.Fatalf("%s: %v", dipperPaths[n], err)
+		}
 		for _, test := range extraTests {
 			path := dipperPaths[n]
 			extra := test.extra
-			id, err := extra.parseID(doc, test.iids[n])
+			id, err := ids.extraID(extra, test.iids[n])
 			if err != nil {
-				t.Errorf("%s (%d): %v", path, extra, err)
+				t.Errorf("%s (%s): %v", path, extra, err)
 			}
 			if id != test.ids[n] {
-				t.Errorf("%s (%d): id = %s, want %s", path, extra, id, test.ids[n])
+				t.Errorf("%s (%s): id = %s, want %s", path, extra, id, test.ids[n])
 			}
 		}
 	}
@@ -0,0 +1,69 @@
+package chilis
+
+import (
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// formFields maps the names of Chili's checkout and payment form fields to the
+// field names used in BadRequestErrors.
+var formFields = map[string]string{
+	"deliveryAddress":    "address",
+	"deliveryAddrPretty": "address",
+	"firstName":          "first name",
+	"lastName":           "last name",
+	"contactPhone":       "phone",
+	"email":              "email",
+	"deliveryDate":       "delivery time",
+	"deliveryTime":       "delivery time",
+	"pickupDate":         "pickup time",
+	"pickupTime":         "pickup time",
+	"cardType":           "credit card company",
+	"cardNumber":         "credit card number",
+	"cvv":                "cvv",
+	"expirationMonth":    "expiration date",
+	"expirationYear":     "expiration date",
+	"nameOnCard":         "name on card",
+	"zipcode":            "zip",
+}
+
+// formReasons maps phrases in the messages of Chili's form-wide errors to the
+// errors returned for them. Messages that don't contain any of them are used
+// as the reasons of ForbiddenErrors as is.
+var formReasons = []struct {
+	phrase string
+	err    ForbiddenError
+}{
+	{"declined", ErrCardDeclined},
+	{"closed", ErrStoreClosed},
+	{"out of range", ErrOutOfRange},
+	{"outside", ErrOutOfRange},
+}
+
+// parseFormError returns the error shown on a Chili's page that was served in
+// response to a rejected form, or nil if the page shows no errors. An error on
+// a field is returned as a BadRequestError naming the field, and an error on
+// the whole form is returned as a ForbiddenError.
+func parseFormError(doc *html.Node) error {
+	if elt, err := selFieldError.findOne(doc); err == nil {
+		name := htmlquery.SelectAttr(elt, "data-field")
+		field, ok := formFields[name]
+		if !ok {
+			field = name
+		}
+		return BadRequestError{field}
+	}
+	if elt, err := selFormError.findOne(doc); err == nil {
+		msg := strings.TrimSpace(htmlquery.InnerText(elt))
+		lower := strings.ToLower(msg)
+		for _, fr := range formReasons {
+			if strings.Contains(lower, fr.phrase) {
+				return fr.err
+			}
+		}
+		return ForbiddenError{msg}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package chilis
+
+import (
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+)
+
+// The error pages are synthetic: Chili's pages with errors added by hand in the
+// markup that parseFormError expects, which hasn't been seen on Chili's.
+var formErrorTests = []struct {
+	path string
+	want error
+}{
+	{"testdata/checkout1.html", nil},
+	{"testdata/confirmation.html", nil},
+	{"testdata/checkout_error_synthetic.html", BadRequestError{"address"}},
+	{"testdata/checkout_closed_synthetic.html", ForbiddenError{"location is closed"}},
+	{"testdata/payment_declined_synthetic.html", ForbiddenError{"card was declined"}},
+}
+
+func TestParseFormError(t *testing.T) {
+	for _, test := range formErrorTests {
+		doc, err := htmlquery.LoadDoc(test.path)
+		if err != nil {
+			log.Fatalf("loading HTML document: %v", err)
+		}
+		err = parseFormError(doc)
+		if err != test.want {
+			t.Errorf("%s: err = %v, want %v", test.path, err, test.want)
+		}
+	}
+}
+
+func FuzzParseFormError(f *testing.F) {
+	for _, test := range formErrorTests {
+		addSeeds(f, filepath.Base(test.path))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseFormError(parseSeed(t, b))
+	})
+}
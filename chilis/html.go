@@ -1,11 +1,9 @@
 package chilis
 
 import (
-	"errors"
+	"context"
 	"fmt"
-	"net/http"
 
-	"github.com/antchfx/htmlquery"
 	"golang.org/x/net/html"
 )
 
@@ -21,66 +19,49 @@ func attrQuery(name, attr, value string) string {
 	return fmt.Sprintf("//%s[@%s='%s']", name, attr, value)
 }
 
-// textQuery returns an XPath query for an HTML element with the given name and
-// inner text.
-func textQuery(name, text string) string {
-	return fmt.Sprintf("//%s[text()='%s']", name, text)
-}
-
-// find finds and returns a slice of HTML elements that match the given XPath
-// query.
-func find(node *html.Node, query string) ([]*html.Node, error) {
-	elts := htmlquery.Find(node, query)
-	if elts == nil {
-		return nil, errors.New("no matching elements found")
-	}
-	return elts, nil
-}
-
-// findOne is the same as find, but it only finds the first HTML element.
-func findOne(node *html.Node, query string) (*html.Node, error) {
-	elt := htmlquery.FindOne(node, query)
-	if elt == nil {
-		return nil, errors.New("no matching elements found")
+// parsePage parses and returns the root node of the HTML document at the given
+// path on the Session's base URL.
+func (s *Session) parsePage(ctx context.Context, path string) (*html.Node, error) {
+	resp, err := s.get(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("fetching HTML at %s: %v", path, err)
 	}
-	return elt, nil
+	defer resp.Body.Close()
+	return html.Parse(resp.Body)
 }
 
-// innerText finds the first HTML element with the given name and class
-// attribute and returns its inner text.
-func innerText(node *html.Node, query string) (string, error) {
-	var it string
-	elt, err := findOne(node, query)
+// parseCartPage is the same as parsePage, but it's used for pages that are
+// only shown when the Session's cart has items in it. It returns
+// ErrSessionExpired if Chili's redirects away from the page or the page's cart
+// is empty, which is what happens once the session has expired.
+func (s *Session) parseCartPage(ctx context.Context, path string) (*html.Node, error) {
+	resp, err := s.get(ctx, path)
 	if err != nil {
-		return it, fmt.Errorf("parsing inner text: %v", err)
+		return nil, fmt.Errorf("fetching HTML at %s: %v", path, err)
 	}
-	return htmlquery.InnerText(elt), nil
-}
-
-// selectAttr finds the first HTML element that matches the given XPath query
-// and returns the value of the given attribute.
-func selectAttr(node *html.Node, query, attr string) (string, error) {
-	var val string
-	elt, err := findOne(node, query)
+	defer resp.Body.Close()
+	if resp.Request.URL.Path != s.base.Path+path {
+		return nil, ErrSessionExpired
+	}
+	doc, err := html.Parse(resp.Body)
 	if err != nil {
-		return val, fmt.Errorf("selecting attribute %s: %v", attr, err)
+		return nil, err
+	}
+	if !cartHasItems(doc) {
+		return nil, ErrSessionExpired
 	}
-	return htmlquery.SelectAttr(elt, attr), nil
+	return doc, nil
 }
 
-// parsePage parses and returns the root node of an HTML document at the given
-// url
-func parsePage(clt *http.Client, u string) (*html.Node, error) {
-	resp, err := clt.Get(u)
-	if err != nil {
-		return nil, fmt.Errorf("fetching HTML at %s: %v", u, err)
-	}
-	defer resp.Body.Close()
-	return html.Parse(resp.Body)
+// cartHasItems reports whether the cart in the header of the given Chili's page
+// has items in it.
+func cartHasItems(doc *html.Node) bool {
+	has, err := selHeaderCart.selectAttr(doc, "data-cart-has-items")
+	return err == nil && has == "true"
 }
 
 // parseCSRFToken parses and returns the CSRF token given any Chili's form
 // page.
 func parseCSRFToken(node *html.Node) (string, error) {
-	return selectAttr(node, attrQuery("input", "name", "_csrf"), "value")
+	return selCSRFToken.selectAttr(node, "value")
 }
@@ -1,54 +1,44 @@
 package chilis
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
 	"net/http/cookiejar"
 	"net/url"
+	"strings"
 
 	"golang.org/x/net/publicsuffix"
 )
 
-// This should never return an error.
-var chilisUrl, _ = url.Parse("https://www.chilis.com")
-
 // createJar creates and returns a cookie jar with secure options (public
 // suffix list set)
-func createJar() (*cookiejar.Jar, error) {
+func createJar() (*recordingJar, error) {
 	options := &cookiejar.Options{PublicSuffixList: publicsuffix.List}
 	jar, err := cookiejar.New(options)
 	if err != nil {
 		return nil, fmt.Errorf("creating cookie jar: %v", err)
 	}
-	return jar, nil
+	return &recordingJar{Jar: jar, cookies: make(map[string]storedCookie)}, nil
 }
 
-// createJar creates and returns a Jar with the given session cookie in it.
-func createSessionJar(session *http.Cookie) (*cookiejar.Jar, error) {
+// createSessionJar creates and returns a Jar with the given session cookie set
+// for the given URL.
+func createSessionJar(u *url.URL, session *http.Cookie) (*recordingJar, error) {
 	jar, err := createJar()
 	if err != nil {
 		return nil, fmt.Errorf("creating session cookie jar: %v", err)
 	}
-	jar.SetCookies(chilisUrl, []*http.Cookie{session})
+	jar.SetCookies(u, []*http.Cookie{session})
 	return jar, nil
 }
 
-// CreateClient creates and returns a client with a jar with the given session
-// cookie in it.
-func createClient(session *http.Cookie) (*http.Client, error) {
-	jar, err := createSessionJar(session)
-	if err != nil {
-		return nil, fmt.Errorf("creating client: %v", err)
-	}
-	return &http.Client{Jar: jar}, err
-}
-
-// sessionID finds and returns the value of the session cookie given an HTTP
-// client.
-func sessionID(client *http.Client) (string, error) {
+// sessionID finds and returns the value of the session cookie for the given
+// URL given an HTTP client.
+func sessionID(client *http.Client, u *url.URL) (string, error) {
 	var sid string
-	for _, cookie := range client.Jar.Cookies(chilisUrl) {
+	for _, cookie := range client.Jar.Cookies(u) {
 		if cookie.Name == "SESSION" {
 			sid = cookie.Value
 			return sid, nil
@@ -56,3 +46,30 @@ func sessionID(client *http.Client) (string, error) {
 	}
 	return sid, errors.New("failed to find session cookie")
 }
+
+// endpoint returns the absolute URL of the given path (which may include a
+// query string) on the Session's base URL.
+func (s *Session) endpoint(path string) string {
+	return s.base.String() + path
+}
+
+// get makes a GET request to the given path on the Session's base URL.
+func (s *Session) get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.endpoint(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.Client.Do(req)
+}
+
+// postForm makes a POST request to the given path on the Session's base URL
+// with the given form as the URL-encoded request body.
+func (s *Session) postForm(ctx context.Context, path string, form url.Values) (*http.Response, error) {
+	body := strings.NewReader(form.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint(path), body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.Client.Do(req)
+}
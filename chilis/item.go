@@ -1,10 +1,6 @@
 package chilis
 
-import (
-	"fmt"
-
-	"golang.org/x/net/html"
-)
+import "fmt"
 
 // An Item is a component of a triple dipper.
 type Item interface {
@@ -12,17 +8,14 @@ type Item interface {
 	ExtraValues() []string
 }
 
-// parseID parses and returns an Item's Chili's ID given its selection index.
-func parseItemID(node *html.Node, val string, i int) (string, error) {
-	var id string
-	text := fmt.Sprintf("Selection %d", i+1)
-	label, err := findOne(node, textQuery("label", text))
-	if err != nil {
-		return id, fmt.Errorf("parsing Item's Chili's ID: %v", err)
+// itemID returns an Item's Chili's ID given its selection index.
+func (ids *optionIDs) itemID(val string, i int) (string, error) {
+	if i >= len(ids.items) {
+		return "", fmt.Errorf("finding Item's Chili's ID: %w", &ParseError{"menu", "item selection"})
 	}
-	id, err = selectAttr(label.Parent, textQuery("option", val), "value")
-	if err != nil {
-		return id, fmt.Errorf("parsing Item's Chili's ID: %v", err)
+	id, ok := ids.items[i][val]
+	if !ok {
+		return "", fmt.Errorf("finding Item's Chili's ID: %w", &ParseError{"menu", "item option"})
 	}
 	return id, nil
 }
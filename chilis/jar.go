@@ -0,0 +1,131 @@
+package chilis
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A storedCookie is a cookie and its attributes as stored in an exported
+// Session.
+type storedCookie struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+	Path   string `json:"path"`
+	// HostOnly is true if the cookie is only sent to Domain itself and not
+	// its subdomains.
+	HostOnly bool `json:"hostOnly"`
+	// Expires is the zero time for session cookies.
+	Expires  time.Time `json:"expires"`
+	Secure   bool      `json:"secure"`
+	HttpOnly bool      `json:"httpOnly"`
+}
+
+// expired reports whether the cookie has expired at the given time.
+func (sc storedCookie) expired(now time.Time) bool {
+	return !sc.Expires.IsZero() && !sc.Expires.After(now)
+}
+
+// recordingJar is an http.CookieJar that keeps a record of the attributes of
+// every cookie it's given so that they can be exported. cookiejar.Jar only
+// returns cookie names and values.
+type recordingJar struct {
+	*cookiejar.Jar
+
+	mu      sync.Mutex
+	cookies map[string]storedCookie
+}
+
+// SetCookies implements http.CookieJar.
+func (j *recordingJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.Jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	for _, c := range cookies {
+		sc := storedCookie{
+			Name:     c.Name,
+			Value:    c.Value,
+			Domain:   strings.TrimPrefix(strings.ToLower(c.Domain), "."),
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+		}
+		if sc.Domain == "" {
+			sc.Domain = strings.ToLower(u.Hostname())
+			sc.HostOnly = true
+		}
+		if sc.Path == "" || sc.Path[0] != '/' {
+			sc.Path = defaultPath(u.Path)
+		}
+		switch {
+		case c.MaxAge < 0:
+			sc.Expires = now
+		case c.MaxAge > 0:
+			sc.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+		key := sc.Domain + ";" + sc.Path + ";" + sc.Name
+		if sc.expired(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		j.cookies[key] = sc
+	}
+}
+
+// stored returns every unexpired cookie in the jar.
+func (j *recordingJar) stored() []storedCookie {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	now := time.Now()
+	var cookies []storedCookie
+	for _, sc := range j.cookies {
+		if !sc.expired(now) {
+			cookies = append(cookies, sc)
+		}
+	}
+	return cookies
+}
+
+// restore adds the given stored cookies to the jar. Requests are made using
+// the given URL's scheme.
+func (j *recordingJar) restore(base *url.URL, cookies []storedCookie) {
+	now := time.Now()
+	for _, sc := range cookies {
+		if sc.expired(now) {
+			continue
+		}
+		u := &url.URL{Scheme: base.Scheme, Host: sc.Domain, Path: sc.Path}
+		c := &http.Cookie{
+			Name:     sc.Name,
+			Value:    sc.Value,
+			Path:     sc.Path,
+			Expires:  sc.Expires,
+			Secure:   sc.Secure,
+			HttpOnly: sc.HttpOnly,
+		}
+		if !sc.HostOnly {
+			c.Domain = sc.Domain
+		}
+		j.SetCookies(u, []*http.Cookie{c})
+	}
+}
+
+// defaultPath returns the default cookie path for the given request path as
+// defined in RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
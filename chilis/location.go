@@ -3,44 +3,176 @@ package chilis
 import (
 	"errors"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/antchfx/htmlquery"
 	"golang.org/x/net/html"
 )
 
-// parseNearestID parses and returns the nearest location's ID, if any, from the
+// A Location is a Chili's restaurant.
+type Location struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Phone   string  `json:"phone"`
+	Address Address `json:"address"`
+}
+
+// A NearbyLocation is a Location found by searching for locations near an
+// address.
+type NearbyLocation struct {
+	Location
+	// Distance is the distance in miles from the searched address.
+	Distance      float64 `json:"distance"`
+	AcceptsOrders bool    `json:"acceptsOrders"`
+	Delivers      bool    `json:"delivers"`
+}
+
+// Available returns a ForbiddenError if the location can't currently take an
+// online delivery order.
+func (nl NearbyLocation) Available() error {
+	if !nl.AcceptsOrders {
+		return ErrNotTakingOrders
+	}
+	if !nl.Delivers {
+		return ErrNoDelivery
+	}
+	return nil
+}
+
+// parseLocations parses and returns every location, nearest first, from the
 // location search page's root node.
-func parseNearestID(doc *html.Node) (string, error) {
-	var id string
-	nearest, err := findOne(doc, classQuery("div", "location"))
+func parseLocations(doc *html.Node) ([]NearbyLocation, error) {
+	elts, err := selLocations.find(doc)
 	if err != nil {
-		return id, ForbiddenError{"no locations in proximity"}
+		return nil, ErrNoLocations
+	}
+	var locs []NearbyLocation
+	for _, elt := range elts {
+		loc, err := parseNearbyLocation(elt)
+		if err != nil {
+			return nil, fmt.Errorf("parsing locations: %v", err)
+		}
+		locs = append(locs, loc)
+	}
+	return locs, nil
+}
+
+// parseNearbyLocation parses and returns a location given its node on the
+// location search page.
+func parseNearbyLocation(node *html.Node) (NearbyLocation, error) {
+	var nl NearbyLocation
+	id := htmlquery.SelectAttr(node, "id")
+	nl.ID = strings.TrimPrefix(id, "location-")
+	if nl.ID == "" || nl.ID == id {
+		return nl, errors.New("parsing location ID")
+	}
+
+	fields := []struct {
+		sel *selector
+		dst *string
+	}{
+		{selNearbyName, &nl.Name},
+		{selNearbyPhone, &nl.Phone},
+		{selNearbyStreet, &nl.Address.Street},
+		{selNearbyCity, &nl.Address.City},
+		{selNearbyState, &nl.Address.State},
+		{selNearbyZip, &nl.Address.Zip},
+	}
+	var err error
+	for _, f := range fields {
+		*f.dst, err = f.sel.innerText(node)
+		if err != nil {
+			return nl, fmt.Errorf("parsing location %s: %w", nl.ID, err)
+		}
 	}
-	// XPath query
-	q := "//a[@class='btn slim order-btn' and text()='Order Now']"
-	_, err = findOne(nearest, q)
+
+	dist, err := selNearbyDistance.innerText(node)
 	if err != nil {
-		return id, ForbiddenError{"location is not accepting online orders"}
+		return nl, fmt.Errorf("parsing location %s: %w", nl.ID, err)
 	}
-	_, err = findOne(nearest, classQuery("span", "delivery icon-doordash"))
+	dist = strings.TrimSuffix(strings.TrimSpace(dist), " miles")
+	nl.Distance, err = strconv.ParseFloat(dist, 64)
 	if err != nil {
-		return id, ForbiddenError{"location doesn't deliver"}
+		return nl, fmt.Errorf("parsing location %s distance: %v", nl.ID, err)
 	}
 
-	id = htmlquery.SelectAttr(nearest, "id")[9:]
-	if id == "" {
-		return id, errors.New("parsing nearest location ID")
+	_, err = selNearbyOrderNow.findOne(node)
+	nl.AcceptsOrders = err == nil
+	_, err = selNearbyDeliveryIcon.findOne(node)
+	nl.Delivers = err == nil
+	return nl, nil
+}
+
+// parseNearestID parses and returns the nearest location's ID, if any, from the
+// location search page's root node.
+func parseNearestID(doc *html.Node) (string, error) {
+	locs, err := parseLocations(doc)
+	if err != nil {
+		return "", err
 	}
-	return id, nil
+	if err := locs[0].Available(); err != nil {
+		return "", err
+	}
+	return locs[0].ID, nil
 }
 
 // parseLocation parses and returns a location from an order confirmation page.
-func parseLocation(doc *html.Node) (string, error) {
-	var loc string
-	wrp, err := findOne(doc, classQuery("div", "location-address-wrapper"))
+func parseLocation(doc *html.Node) (Location, error) {
+	var loc Location
+	wrp, err := selConfirmedLocation.findOne(doc)
+	if err != nil {
+		return loc, fmt.Errorf("parsing location: %w", err)
+	}
+
+	fields := []struct {
+		sel *selector
+		dst *string
+	}{
+		{selConfirmedName, &loc.Name},
+		{selConfirmedPhone, &loc.Phone},
+		{selConfirmedStreet, &loc.Address.Street},
+		{selConfirmedCity, &loc.Address.City},
+		{selConfirmedState, &loc.Address.State},
+		{selConfirmedZip, &loc.Address.Zip},
+	}
+	for _, f := range fields {
+		*f.dst, err = f.sel.innerText(wrp)
+		if err != nil {
+			return loc, fmt.Errorf("parsing location: %w", err)
+		}
+	}
+
+	loc.ID, err = parseLocationID(doc)
 	if err != nil {
 		return loc, fmt.Errorf("parsing location: %v", err)
 	}
+	return loc, nil
+}
 
-	return innerText(wrp, classQuery("div", "location-name"))
+// parseLocationID parses and returns the restaurant ID of the location that an
+// order was placed at from the links on an order confirmation page.
+func parseLocationID(doc *html.Node) (string, error) {
+	links := []struct {
+		sel   *selector
+		param string
+	}{
+		{selRegisterLink, "pr"},
+		{selTrackingLink, "rid"},
+	}
+	for _, l := range links {
+		href, err := l.sel.selectAttr(doc, "href")
+		if err != nil {
+			continue
+		}
+		u, err := url.Parse(href)
+		if err != nil {
+			continue
+		}
+		if id := u.Query().Get(l.param); id != "" {
+			return id, nil
+		}
+	}
+	return "", errors.New("failed to find location ID")
 }
@@ -101,6 +101,7 @@ func TestParseLocation(t *testing.T) {
 	// No need to do more than one
 	path := "testdata/confirmation.html"
 	test := Location{
+		ID:    "001.005.0115",
 		Name:  "Durham 15/501",
 		Phone: "(919) 489-6699",
 		Address: Address{
@@ -122,3 +123,77 @@ func TestParseLocation(t *testing.T) {
 		t.Errorf("%s: location = %s, want %s", path, location, test)
 	}
 }
+
+func TestParseLocations(t *testing.T) {
+	// No need to do more than one
+	path := "testdata/location1.html"
+	n := 12
+	test := NearbyLocation{
+		Location: Location{
+			ID:    "001.005.0945",
+			Name:  "Largo Mall",
+			Phone: "(727) 581-3557",
+			Address: Address{
+				Street: "13359 Seminole Blvd",
+				City:   "Largo",
+				State:  "FL",
+				Zip:    "33778",
+			},
+		},
+		Distance:      2.51,
+		AcceptsOrders: true,
+		Delivers:      true,
+	}
+	doc, err := htmlquery.LoadDoc(path)
+	if err != nil {
+		t.Errorf("%s: %v", path, err)
+	}
+	locs, err := parseLocations(doc)
+	if err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	if len(locs) != n {
+		t.Errorf("%s: len(locations) = %d, want %d", path, len(locs), n)
+	}
+	if locs[0] != test {
+		t.Errorf("%s: locations[0] = %+v, want %+v", path, locs[0], test)
+	}
+	if locs[1].ID != "001.005.0105" || locs[1].Distance != 6.26 {
+		t.Errorf("%s: locations[1] = %+v, want 001.005.0105 at 6.26 miles", path, locs[1])
+	}
+}
+
+func TestParseLocationsNoDelivery(t *testing.T) {
+	for _, test := range noDeliveryTests {
+		doc, err := htmlquery.LoadDoc(test)
+		if err != nil {
+			t.Errorf("%s: %v", test, err)
+		}
+		locs, err := parseLocations(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", test, err)
+		}
+		if locs[0].Delivers {
+			t.Errorf("%s: locations[0].Delivers = true, want false", test)
+		}
+		if locs[0].Available() == nil {
+			t.Errorf("%s: locations[0].Available() = nil, want error", test)
+		}
+	}
+}
+
+func FuzzParseLocations(f *testing.F) {
+	addSeeds(f, "location*.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		doc := parseSeed(t, b)
+		parseLocations(doc)
+		parseNearestID(doc)
+	})
+}
+
+func FuzzParseLocation(f *testing.F) {
+	addSeeds(f, "confirmation.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseLocation(parseSeed(t, b))
+	})
+}
@@ -1,8 +1,10 @@
 package chilis
 
 import (
-	"fmt"
+	"bytes"
+	"log"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/antchfx/htmlquery"
@@ -23,9 +25,34 @@ func TestMain(m *testing.M) {
 	for _, path := range dipperPaths {
 		doc, err := htmlquery.LoadDoc(path)
 		if err != nil {
-			fmt.Errorf("%s: %v", path, err)
+			log.Fatalf("%s: %v", path, err)
 		}
 		dipperDocs = append(dipperDocs, doc)
 	}
 	os.Exit(m.Run())
 }
+
+// addSeeds adds the contents of every file in testdata that matches the given
+// pattern to the seed corpus of a fuzz target.
+func addSeeds(f *testing.F, pattern string) {
+	paths, err := filepath.Glob(filepath.Join("testdata", pattern))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, path := range paths {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+}
+
+// parseSeed parses a fuzzed HTML document.
+func parseSeed(t *testing.T, b []byte) *html.Node {
+	doc, err := htmlquery.Parse(bytes.NewReader(b))
+	if err != nil {
+		t.Skip()
+	}
+	return doc
+}
@@ -0,0 +1,97 @@
+package chilis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A Menu is what a location offers on its menu page for a Product.
+type Menu struct {
+	// Description is the Product's description. Chili's doesn't describe
+	// the items individually.
+	Description string
+	// Items are the items that can be selected, sorted by name.
+	Items []MenuItem
+}
+
+// A MenuItem is an item that can be selected for a Product and the extras that
+// are permitted with it.
+type MenuItem struct {
+	Name string
+	// Extras are the names of the permitted extras, sorted.
+	Extras []string
+	// Groups are the indexes of the Product's selection groups that offer
+	// the item, in order.
+	Groups []int
+}
+
+// noExtra is the option that some items have for choosing none of their
+// extras. It isn't an extra itself.
+const noExtra = "No Sauce"
+
+// Menu returns the Menu of the Session's location for the given Product. It
+// also refreshes the cached IDs of the Menu's items and extras.
+func (s *Session) Menu(ctx context.Context, p Product) (Menu, error) {
+	doc, err := s.parsePage(ctx, p.Path)
+	if err != nil {
+		return Menu{}, fmt.Errorf("fetching menu: %v", err)
+	}
+	m, ids, err := parseMenu(doc, p)
+	if err != nil {
+		return m, s.snapshot(fmt.Errorf("parsing menu: %w", err))
+	}
+	if csrf, err := parseCSRFToken(doc); err == nil {
+		s.csrf = csrf
+	}
+	s.menus.put(s.LocationID, p.ID, ids)
+	return m, nil
+}
+
+// parseMenu parses and returns the Menu on the given menu page for the given
+// Product and the IDs of its items and extras.
+func parseMenu(doc *html.Node, p Product) (Menu, *optionIDs, error) {
+	var m Menu
+	desc, err := selMenuDescription.innerText(doc)
+	if err != nil {
+		return m, nil, err
+	}
+	m.Description = strings.TrimSpace(desc)
+	ids, err := parseOptionIDs(doc, p)
+	if err != nil {
+		return m, nil, err
+	}
+
+	// Selection groups can offer the same items with different IDs, so the
+	// extras permitted with an item are merged across groups.
+	extras := make(map[string]map[string]bool)
+	groups := make(map[string][]int)
+	for i, items := range ids.items {
+		for name, iid := range items {
+			groups[name] = append(groups[name], i)
+			if extras[name] == nil {
+				extras[name] = make(map[string]bool)
+			}
+			for e := range ids.extras[iid] {
+				if e != noExtra {
+					extras[name][e] = true
+				}
+			}
+		}
+	}
+	for name, es := range extras {
+		it := MenuItem{Name: name, Extras: []string{}, Groups: groups[name]}
+		for e := range es {
+			it.Extras = append(it.Extras, e)
+		}
+		sort.Strings(it.Extras)
+		m.Items = append(m.Items, it)
+	}
+	sort.Slice(m.Items, func(i, j int) bool {
+		return m.Items[i].Name < m.Items[j].Name
+	})
+	return m, ids, nil
+}
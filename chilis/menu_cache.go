@@ -0,0 +1,155 @@
+package chilis
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// DefaultMenuTTL is how long the option IDs on a location's menu page for a
+// Product are cached if a Client's MenuTTL isn't set.
+const DefaultMenuTTL = time.Hour
+
+// optionIDs are the Chili's IDs of the items and extras on a location's menu
+// page for a Product. They differ between locations.
+type optionIDs struct {
+	// items maps item names to IDs for each selection group, in order.
+	items []map[string]string
+	// extras maps extra names to IDs for each item ID.
+	extras map[string]map[string]string
+}
+
+// parseOptionIDs parses and returns the IDs of every item and extra on the
+// given menu page for the given Product.
+func parseOptionIDs(doc *html.Node, p Product) (*optionIDs, error) {
+	ids := &optionIDs{extras: make(map[string]map[string]string)}
+	for _, g := range p.Groups {
+		label, err := selItemLabel.findOne(doc, g)
+		if err != nil {
+			return nil, err
+		}
+		opts, err := selItemOptions.find(label.Parent)
+		if err != nil {
+			return nil, err
+		}
+		ids.items = append(ids.items, optionValues(opts))
+	}
+
+	grps, err := selExtraGroups.find(doc)
+	if err != nil {
+		return nil, err
+	}
+	for _, grp := range grps {
+		opts, err := selExtraOptions.find(grp)
+		if err != nil {
+			// Groups of checkboxes have no options.
+			continue
+		}
+		iid := htmlquery.SelectAttr(grp, "data-related")
+		extras, ok := ids.extras[iid]
+		if !ok {
+			extras = make(map[string]string)
+			ids.extras[iid] = extras
+		}
+		for name, id := range optionValues(opts) {
+			if _, ok := extras[name]; !ok {
+				extras[name] = id
+			}
+		}
+	}
+	return ids, nil
+}
+
+// optionValues maps the names of the given option elements to their values.
+// Options without values are placeholders, so they're left out. If more than
+// one option has the same name, the first is used.
+func optionValues(opts []*html.Node) map[string]string {
+	vals := make(map[string]string)
+	for _, opt := range opts {
+		val := htmlquery.SelectAttr(opt, "value")
+		name := strings.TrimSpace(htmlquery.InnerText(opt))
+		if _, ok := vals[name]; ok || val == "" {
+			continue
+		}
+		vals[name] = val
+	}
+	return vals
+}
+
+// menuCache caches the option IDs on locations' menu pages by restaurant ID and
+// Product ID. A Client's Sessions share its menuCache. A nil menuCache caches
+// nothing.
+type menuCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[menuKey]menuEntry
+}
+
+// A menuKey identifies a location's menu page for a Product.
+type menuKey struct {
+	lid string
+	pid string
+}
+
+// A menuEntry is a location's cached option IDs.
+type menuEntry struct {
+	ids     *optionIDs
+	expires time.Time
+}
+
+// newMenuCache returns a pointer to a new menuCache whose entries expire after
+// the given TTL. If the TTL is negative, nothing is cached.
+func newMenuCache(ttl time.Duration) *menuCache {
+	if ttl == 0 {
+		ttl = DefaultMenuTTL
+	}
+	return &menuCache{ttl: ttl, now: time.Now, entries: make(map[menuKey]menuEntry)}
+}
+
+// get returns the cached option IDs for the Product with the given ID at the
+// location with the given restaurant ID, or nil if they aren't cached or have
+// expired.
+func (mc *menuCache) get(lid, pid string) *optionIDs {
+	if mc == nil || lid == "" {
+		return nil
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	key := menuKey{lid, pid}
+	e, ok := mc.entries[key]
+	if !ok {
+		return nil
+	}
+	if !mc.now().Before(e.expires) {
+		delete(mc.entries, key)
+		return nil
+	}
+	return e.ids
+}
+
+// put caches the option IDs for the Product with the given ID at the location
+// with the given restaurant ID.
+func (mc *menuCache) put(lid, pid string, ids *optionIDs) {
+	if mc == nil || lid == "" || mc.ttl < 0 {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	mc.entries[menuKey{lid, pid}] = menuEntry{ids, mc.now().Add(mc.ttl)}
+}
+
+// invalidate removes the cached option IDs for the Product with the given ID at
+// the location with the given restaurant ID.
+func (mc *menuCache) invalidate(lid, pid string) {
+	if mc == nil {
+		return
+	}
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	delete(mc.entries, menuKey{lid, pid})
+}
@@ -0,0 +1,186 @@
+package chilis
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+func TestOptionValues(t *testing.T) {
+	doc, err := htmlquery.Parse(strings.NewReader(`<html><body>
+<option value="" data-is-placeholder="true">-- Select Any One --</option>
+<option value="1">Chili's Queso</option>
+<option value="2">Chili's "Big" Bites</option>
+<option value="3">Chili's Queso</option>
+</body></html>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := optionValues(htmlquery.Find(doc, "//option"))
+	want := map[string]string{`Chili's Queso`: "1", `Chili's "Big" Bites`: "2"}
+	if len(got) != len(want) {
+		t.Errorf("values = %v, want %v", got, want)
+	}
+	for name, id := range want {
+		if got[name] != id {
+			t.Errorf("%s: value = %s, want %s", name, got[name], id)
+		}
+	}
+}
+
+func TestMenuCacheTTL(t *testing.T) {
+	now := time.Now()
+	mc := newMenuCache(time.Minute)
+	mc.now = func() time.Time { return now }
+	ids := &optionIDs{}
+
+	pid := TripleDipperProduct.ID
+	mc.put("001.005.0945", pid, ids)
+	if mc.get("001.005.0945", pid) != ids {
+		t.Fatal("IDs weren't cached")
+	}
+	if mc.get("001.005.0115", pid) != nil {
+		t.Error("IDs were cached for another location")
+	}
+	if mc.get("001.005.0945", "other") != nil {
+		t.Error("IDs were cached for another product")
+	}
+	now = now.Add(time.Minute)
+	if mc.get("001.005.0945", pid) != nil {
+		t.Error("IDs were cached past their TTL")
+	}
+
+	mc = newMenuCache(-1)
+	mc.put("001.005.0945", pid, ids)
+	if mc.get("001.005.0945", pid) != nil {
+		t.Error("IDs were cached with a negative TTL")
+	}
+}
+
+// countingTransport counts the GET requests made to each path.
+type countingTransport struct {
+	mu   sync.Mutex
+	gets map[string]int
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method == http.MethodGet {
+		t.mu.Lock()
+		t.gets[req.URL.Path]++
+		t.mu.Unlock()
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// dipperFetches returns the number of times that the triple dipper's menu page
+// was fetched.
+func (t *countingTransport) dipperFetches() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.gets[TripleDipperProduct.Path]
+}
+
+// cartCount returns the number of lines in the cart of the given session on
+// the given server.
+func cartCount(srv *chilistest.Server, sess *Session) int {
+	state, _ := srv.Session(sess.ID)
+	return len(state.Cart)
+}
+
+func TestSessionCartCachedMenu(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	ct := &countingTransport{gets: make(map[string]int)}
+	c := &Client{BaseURL: srv.URL, Transport: ct}
+
+	for i := 0; i < 2; i++ {
+		sess, err := c.StartSession(ctx)
+		if err != nil {
+			t.Fatalf("StartSession: %v", err)
+		}
+		if err := sess.SetLocation(ctx, testAddress); err != nil {
+			t.Fatalf("SetLocation: %v", err)
+		}
+		for j := 0; j < 3; j++ {
+			if err := sess.Cart(ctx, testTripleDipper); err != nil {
+				t.Fatalf("Cart: %v", err)
+			}
+		}
+		if n := cartCount(srv, sess); n != 3 {
+			t.Errorf("session %d: len(cart) = %d, want 3", i, n)
+		}
+	}
+	if n := ct.dipperFetches(); n != 1 {
+		t.Errorf("fetched triple dipper page %d times, want 1", n)
+	}
+}
+
+func TestSessionCartMenuMiss(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	ct := &countingTransport{gets: make(map[string]int)}
+	c := &Client{BaseURL: srv.URL, Transport: ct}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	// Cache a menu that doesn't have the TripleDipper's items.
+	sess.menus.put(sess.LocationID, TripleDipperProduct.ID, &optionIDs{})
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	if n := ct.dipperFetches(); n != 1 {
+		t.Errorf("fetched triple dipper page %d times, want 1", n)
+	}
+	if ids := sess.menus.get(sess.LocationID, TripleDipperProduct.ID); ids == nil || len(ids.items) != 3 {
+		t.Errorf("cached IDs = %+v, want the fetched page's", ids)
+	}
+	if n := cartCount(srv, sess); n != 1 {
+		t.Errorf("len(cart) = %d, want 1", n)
+	}
+}
+
+func TestSessionCartExpiredCSRF(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	srv.Expire(sess.ID)
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart after expiry: %v", err)
+	}
+}
+
+func FuzzParseOptionIDs(f *testing.F) {
+	addSeeds(f, "dipper*.html")
+	f.Fuzz(func(t *testing.T, b []byte) {
+		ids, err := parseOptionIDs(parseSeed(t, b), TripleDipperProduct)
+		if err == nil {
+			comboForm(ids, "", testTripleDipper)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package chilis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CartItem adds the given quantity of the menu item on the page at the given
+// path to the Session's cart with the options with the given Chili's IDs
+// selected. Use MenuOptions to find the IDs, since they differ between
+// locations.
+func (s *Session) CartItem(ctx context.Context, menuPath string, optionIDs []string, qty int) error {
+	if qty < 1 {
+		return fmt.Errorf("adding menu item to cart: %w", BadRequestError{"quantity"})
+	}
+	cached := s.csrf != ""
+	for {
+		if !cached {
+			doc, err := s.parsePage(ctx, menuPath)
+			if err != nil {
+				return fmt.Errorf("fetching menu item page: %v", err)
+			}
+			s.csrf, err = parseCSRFToken(doc)
+			if err != nil {
+				return s.snapshot(fmt.Errorf("parsing menu item page: %w", err))
+			}
+		}
+
+		form := url.Values{
+			"_csrf":       []string{s.csrf},
+			"selectedIds": optionIDs,
+			"quantity":    []string{strconv.Itoa(qty)},
+		}
+		resp, err := s.postForm(ctx, menuPath, form)
+		if err != nil {
+			return fmt.Errorf("posting cart request: %v", err)
+		}
+		if resp.StatusCode == http.StatusForbidden && cached {
+			// The CSRF token is no longer valid if the session expired.
+			resp.Body.Close()
+			cached = false
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("reading cart response body: %v", err)
+		}
+		return s.snapshot(parseCart(body))
+	}
+}
+
+// MenuOptions returns the Chili's IDs of the options on the menu item page at
+// the given path for the Session's location, by name.
+func (s *Session) MenuOptions(ctx context.Context, menuPath string) (map[string]string, error) {
+	doc, err := s.parsePage(ctx, menuPath)
+	if err != nil {
+		return nil, fmt.Errorf("fetching menu item page: %v", err)
+	}
+	if csrf, err := parseCSRFToken(doc); err == nil {
+		s.csrf = csrf
+	}
+	opts, err := selMenuItemOptions.find(doc)
+	var perr *ParseError
+	if errors.As(err, &perr) {
+		// Some menu items have no options.
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing menu item page: %w", err)
+	}
+	return optionValues(opts), nil
+}
@@ -0,0 +1,56 @@
+package chilis
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+func TestSessionCartItem(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	path := "/menu/sides/loaded-mashed-potatoes"
+	srv.MenuItems = map[string]string{path: "Loaded Mashed Potatoes"}
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	opts, err := sess.MenuOptions(ctx, path)
+	if err != nil {
+		t.Fatalf("MenuOptions: %v", err)
+	}
+	id, ok := opts["Fried Pickles"]
+	if !ok {
+		t.Fatalf("options = %v, want Fried Pickles", opts)
+	}
+	if err := sess.CartItem(ctx, path, []string{id}, 2); err != nil {
+		t.Fatalf("CartItem: %v", err)
+	}
+	err = sess.CartItem(ctx, path, nil, 0)
+	var bre BadRequestError
+	if !errors.As(err, &bre) || bre.Field != "quantity" {
+		t.Errorf("CartItem with no quantity: err = %v, want invalid quantity", err)
+	}
+
+	lines, err := sess.CartContents(ctx)
+	if err != nil {
+		t.Fatalf("CartContents: %v", err)
+	}
+	if len(lines) != 1 {
+		t.Fatalf("len(lines) = %d, want 1", len(lines))
+	}
+	if !lines[0].MatchesItem("Loaded Mashed Potatoes", 2, []string{"Fried Pickles"}) {
+		t.Errorf("line %+v doesn't match", lines[0])
+	}
+	if lines[0].MatchesItem("Loaded Mashed Potatoes", 1, []string{"Fried Pickles"}) {
+		t.Errorf("line %+v matches a different quantity", lines[0])
+	}
+}
@@ -0,0 +1,66 @@
+package chilis
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+var menuTests = []MenuItem{
+	{"Awesome Blossom Petals", []string{"Avocado-Ranch Dressing", "Ranch Dressing"}, []int{0, 1, 2}},
+	{"Big Mouth® Bites", []string{"Ranch Dressing"}, []int{0, 1, 2}},
+	{"Crispy Cheddar Bites", []string{"Ancho-Chile Ranch Dressing"}, []int{0, 1, 2}},
+	{"Original Chicken Crispers®", []string{"Honey-Mustard Dressing", "Original BBQ Sauce", "Ranch Dressing"}, []int{0, 1, 2}},
+}
+
+func TestParseMenu(t *testing.T) {
+	for n, doc := range dipperDocs {
+		path := dipperPaths[n]
+		m, _, err := parseMenu(doc, TripleDipperProduct)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if m.Description == "" {
+			t.Errorf("%s: description is empty", path)
+		}
+		if len(m.Items) != len(itemTests) {
+			t.Errorf("%s: len(items) = %d, want %d", path, len(m.Items), len(itemTests))
+		}
+		items := make(map[string]MenuItem)
+		for _, it := range m.Items {
+			items[it.Name] = it
+		}
+		for _, want := range menuTests {
+			if got := items[want.Name]; !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: item = %+v, want %+v", path, got, want)
+			}
+		}
+	}
+}
+
+func TestSessionMenu(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocationByID(ctx, "001.005.0945"); err != nil {
+		t.Fatalf("SetLocationByID: %v", err)
+	}
+	m, err := sess.Menu(ctx, TripleDipperProduct)
+	if err != nil {
+		t.Fatalf("Menu: %v", err)
+	}
+	if len(m.Items) != len(itemTests) {
+		t.Errorf("len(items) = %d, want %d", len(m.Items), len(itemTests))
+	}
+	if sess.menus.get(sess.LocationID, TripleDipperProduct.ID) == nil {
+		t.Error("Menu didn't cache the location's IDs")
+	}
+}
@@ -0,0 +1,121 @@
+package chilis
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount of US dollars in cents.
+type Money int64
+
+// ParseMoney parses and returns an amount of money written in dollars, like
+// "$1,234.56", "-$0.93", or "13.2". Amounts with fractions of a cent are
+// invalid.
+func ParseMoney(s string) (Money, error) {
+	orig := s
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	s = strings.TrimPrefix(s, "$")
+	s = strings.ReplaceAll(s, ",", "")
+
+	dollars, cents := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		dollars, cents = s[:i], s[i+1:]
+	}
+	if dollars == "" && cents == "" || len(cents) > 2 {
+		return 0, fmt.Errorf("parsing money %q: invalid amount", orig)
+	}
+	for len(cents) < 2 {
+		cents += "0"
+	}
+	var m int64
+	for _, part := range []string{dollars, cents} {
+		for _, r := range part {
+			if r < '0' || r > '9' {
+				return 0, fmt.Errorf("parsing money %q: invalid amount", orig)
+			}
+		}
+	}
+	if dollars != "" {
+		d, err := strconv.ParseInt(dollars, 10, 64)
+		if err != nil || d > math.MaxInt64/100-1 {
+			return 0, fmt.Errorf("parsing money %q: invalid amount", orig)
+		}
+		m = d * 100
+	}
+	c, _ := strconv.ParseInt(cents, 10, 64)
+	m += c
+	if neg {
+		m = -m
+	}
+	return Money(m), nil
+}
+
+// Decimal returns the amount in dollars without a dollar sign, like "13.19".
+func (m Money) Decimal() string {
+	sign := ""
+	c := int64(m)
+	if c < 0 {
+		sign = "-"
+		c = -c
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, c/100, c%100)
+}
+
+// String returns the amount in dollars, like "$13.19".
+func (m Money) String() string {
+	if m < 0 {
+		return "-$" + (-m).Decimal()
+	}
+	return "$" + m.Decimal()
+}
+
+// MarshalJSON implements json.Marshaler. Money is encoded as a number of
+// dollars with exactly two decimal places.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.Decimal()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (m *Money) UnmarshalJSON(b []byte) error {
+	pm, err := ParseMoney(strings.Trim(string(b), `"`))
+	if err != nil {
+		return err
+	}
+	*m = pm
+	return nil
+}
+
+// Value implements driver.Valuer. Money is stored as a decimal number of
+// dollars.
+func (m Money) Value() (driver.Value, error) {
+	return m.Decimal(), nil
+}
+
+// Scan implements sql.Scanner.
+func (m *Money) Scan(src interface{}) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+	case int64:
+		*m = Money(v * 100)
+	case float64:
+		*m = Money(math.Round(v * 100))
+	case []byte:
+		*m, err = ParseMoney(string(v))
+	case string:
+		*m, err = ParseMoney(v)
+	default:
+		err = errors.New("unsupported type")
+	}
+	if err != nil {
+		return fmt.Errorf("scanning money: %v", err)
+	}
+	return nil
+}
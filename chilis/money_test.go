@@ -0,0 +1,91 @@
+package chilis
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var moneyTests = []struct {
+	in   string
+	want Money
+	str  string
+}{
+	{"$13.19", 1319, "$13.19"},
+	{"$0.93", 93, "$0.93"},
+	{"13.2", 1320, "$13.20"},
+	{"$1,234.56", 123456, "$1234.56"},
+	{"-$3.99", -399, "-$3.99"},
+	{"$7", 700, "$7.00"},
+	{" $.5 ", 50, "$0.50"},
+}
+
+func TestParseMoney(t *testing.T) {
+	for _, test := range moneyTests {
+		m, err := ParseMoney(test.in)
+		if err != nil {
+			t.Errorf("ParseMoney(%q): %v", test.in, err)
+			continue
+		}
+		if m != test.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", test.in, m, test.want)
+		}
+		if m.String() != test.str {
+			t.Errorf("ParseMoney(%q).String() = %s, want %s", test.in, m, test.str)
+		}
+	}
+}
+
+func TestParseMoneyInvalid(t *testing.T) {
+	for _, in := range []string{"", "$", "$1.234", "12.3.4", "$1a.00", "$-1.00", "1e3"} {
+		if _, err := ParseMoney(in); err == nil {
+			t.Errorf("ParseMoney(%q): err = nil, want error", in)
+		}
+	}
+}
+
+func TestMoneyScan(t *testing.T) {
+	srcs := []interface{}{[]byte("13.19"), "13.19", 13.19, int64(13)}
+	wants := []Money{1319, 1319, 1319, 1300}
+	for i, src := range srcs {
+		var m Money
+		if err := m.Scan(src); err != nil {
+			t.Errorf("Scan(%v): %v", src, err)
+		}
+		if m != wants[i] {
+			t.Errorf("Scan(%v) = %d, want %d", src, m, wants[i])
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	b, err := json.Marshal(struct{ Price Money }{1319})
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(b) != `{"Price":13.19}` {
+		t.Errorf("Marshal = %s, want {\"Price\":13.19}", b)
+	}
+	var v struct{ Price Money }
+	if err := json.Unmarshal(b, &v); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if v.Price != 1319 {
+		t.Errorf("Unmarshal = %d, want 1319", v.Price)
+	}
+}
+
+func FuzzParseMoney(f *testing.F) {
+	for _, test := range moneyTests {
+		f.Add(test.in)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		m, err := ParseMoney(s)
+		if err != nil {
+			return
+		}
+		again, err := ParseMoney(m.String())
+		if err != nil || again != m {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", m.String(), again, err, m)
+		}
+	})
+}
@@ -0,0 +1,19 @@
+package chilis
+
+// An OrderMode is the way that a customer receives an order.
+type OrderMode string
+
+// The order modes that Chili's supports online.
+const (
+	Delivery OrderMode = "delivery"
+	Pickup   OrderMode = "pickup"
+)
+
+// unavailable returns the error returned when a location isn't currently
+// taking orders in the mode.
+func (m OrderMode) unavailable() error {
+	if m == Pickup {
+		return ErrNotTakingPickup
+	}
+	return ErrNotDelivering
+}
@@ -0,0 +1,148 @@
+package chilis
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultBackoff is the time waited before the first retry of a failed request
+// if a Client's Backoff isn't set.
+const DefaultBackoff = 500 * time.Millisecond
+
+// An Attempt describes one attempt at making an HTTP request to Chili's.
+type Attempt struct {
+	Method string
+	URL    string
+	// N is the number of the attempt, starting at 1.
+	N          int
+	StatusCode int
+	Err        error
+	Duration   time.Duration
+}
+
+// pacedTransport is an http.RoundTripper that makes one request at a time,
+// waits at least gap between the start of consecutive requests, and retries
+// idempotent requests that fail transiently with exponential backoff. Chili's
+// servers don't handle concurrent requests from a session well.
+type pacedTransport struct {
+	rt      http.RoundTripper
+	gap     time.Duration
+	retries int
+	backoff time.Duration
+	observe func(Attempt)
+
+	// sem is held from the start of a request until its response body is
+	// closed.
+	sem chan struct{}
+	// last is the start time of the latest attempt. It's guarded by sem.
+	last time.Time
+}
+
+// newPacedTransport returns a pacedTransport that wraps the given RoundTripper
+// and is configured according to the given Client.
+func newPacedTransport(rt http.RoundTripper, c *Client) *pacedTransport {
+	backoff := c.Backoff
+	if backoff == 0 {
+		backoff = DefaultBackoff
+	}
+	return &pacedTransport{
+		rt:      rt,
+		gap:     c.MinGap,
+		retries: c.Retries,
+		backoff: backoff,
+		observe: c.OnAttempt,
+		sem:     make(chan struct{}, 1),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *pacedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	select {
+	case t.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	resp, err := t.roundTrip(req)
+	if err != nil {
+		<-t.sem
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: func() { <-t.sem }}
+	return resp, nil
+}
+
+// roundTrip makes the request, retrying it if it's idempotent and fails
+// transiently. The caller must hold sem.
+func (t *pacedTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	retries := 0
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		retries = t.retries
+	}
+	delay := t.backoff
+	for n := 1; ; n++ {
+		if err := sleep(ctx, time.Until(t.last.Add(t.gap))); err != nil {
+			return nil, err
+		}
+		t.last = time.Now()
+		resp, err := t.rt.RoundTrip(req)
+		if t.observe != nil {
+			a := Attempt{
+				Method:   req.Method,
+				URL:      req.URL.String(),
+				N:        n,
+				Err:      err,
+				Duration: time.Since(t.last),
+			}
+			if resp != nil {
+				a.StatusCode = resp.StatusCode
+			}
+			t.observe(a)
+		}
+		transient := err != nil || resp.StatusCode >= 500
+		if !transient || n > retries || ctx.Err() != nil {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		delay *= 2
+	}
+}
+
+// sleep waits for the given duration or until the context is done, in which
+// case it returns the context's error.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// releasingBody is a response body that calls release once when it's closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close implements io.Closer.
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
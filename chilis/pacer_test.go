@@ -0,0 +1,129 @@
+package chilis
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPacerRetriesGet(t *testing.T) {
+	ctx := context.Background()
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		if hits < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	var attempts []Attempt
+	c := &Client{
+		BaseURL:   srv.URL,
+		Retries:   2,
+		Backoff:   time.Millisecond,
+		OnAttempt: func(a Attempt) { attempts = append(attempts, a) },
+	}
+	sess, err := c.NewSession("abc123")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	resp, err := sess.get(ctx, "/")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("StatusCode = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("len(attempts) = %d, want 3", len(attempts))
+	}
+	for i, a := range attempts {
+		if a.N != i+1 {
+			t.Errorf("attempts[%d].N = %d, want %d", i, a.N, i+1)
+		}
+	}
+	if attempts[0].StatusCode != http.StatusBadGateway {
+		t.Errorf("attempts[0].StatusCode = %d, want %d", attempts[0].StatusCode, http.StatusBadGateway)
+	}
+}
+
+func TestPacerDoesNotRetryPost(t *testing.T) {
+	ctx := context.Background()
+	var hits int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, Retries: 3, Backoff: time.Millisecond}
+	sess, err := c.NewSession("abc123")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	resp, err := sess.postForm(ctx, "/order/payment", url.Values{})
+	if err != nil {
+		t.Fatalf("postForm: %v", err)
+	}
+	resp.Body.Close()
+	if hits != 1 {
+		t.Errorf("hits = %d, want 1", hits)
+	}
+}
+
+func TestPacerSerializesAndPaces(t *testing.T) {
+	ctx := context.Background()
+	gap := 20 * time.Millisecond
+	var mu sync.Mutex
+	var active, maxActive int
+	var starts []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	c := &Client{BaseURL: srv.URL, MinGap: gap}
+	sess, err := c.NewSession("abc123")
+	if err != nil {
+		t.Fatalf("NewSession: %v", err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := sess.get(ctx, "/")
+			if err != nil {
+				t.Errorf("get: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if maxActive != 1 {
+		t.Errorf("max concurrent requests = %d, want 1", maxActive)
+	}
+	for i := 1; i < len(starts); i++ {
+		if d := starts[i].Sub(starts[i-1]); d < gap-time.Millisecond {
+			t.Errorf("gap between requests %d and %d = %v, want >= %v", i-1, i, d, gap)
+		}
+	}
+}
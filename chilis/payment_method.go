@@ -13,19 +13,19 @@ import (
 type PaymentMethod struct {
 	Number  string `json:"number"`
 	CVV     string `json:"cvv"`
-	Month   string `json:"month`
+	Month   string `json:"month"`
 	Year    string `json:"year"`
 	Name    string `json:"name"`
-	Zip     string `json:"zip`
+	Zip     string `json:"zip"`
 	Company string `json:"company"`
 }
 
 // form validates the payment method and adds all of its fields to a form map
-// with default values set.
-func (pm *PaymentMethod) form(doc *html.Node) (url.Values, error) {
+// with default values set for an order in the given mode.
+func (pm *PaymentMethod) form(doc *html.Node, mode OrderMode) (url.Values, error) {
 	form := url.Values{}
 	form.Add("paymentMethod", "creditcard")
-	form.Add("orderMode", "delivery")
+	form.Add("orderMode", string(mode))
 	form.Add("cardType", pm.Company)
 	form.Add("cvv", pm.CVV)
 	form.Add("expirationMonth", pm.Month)
@@ -85,6 +85,9 @@ func (pm *PaymentMethod) validate() error {
 func (pm *PaymentMethod) format() (string, error) {
 	var f string
 	c := pm.Number
+	if len(c) < 13 {
+		return f, BadRequestError{"credit card number"}
+	}
 	switch pm.Company {
 	case "visa", "mastercard", "discover":
 		f = fmt.Sprintf("%s-%s-%s-%s", c[:4], c[4:8], c[8:12], c[12:])
@@ -0,0 +1,93 @@
+package chilis
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+)
+
+// A Product is a Chili's combo that's built by selecting one item from each of
+// the selection groups on its menu page, like the triple dipper.
+type Product struct {
+	// ID identifies the Product. It's what orders are stored with.
+	ID   string
+	Name string
+	// Path is the path of the Product's menu page.
+	Path string
+	// Groups are the labels of the Product's selection groups on its menu
+	// page, in the order that items are selected.
+	Groups []string
+}
+
+// Selections returns the number of items that are selected for the Product.
+func (p Product) Selections() int {
+	return len(p.Groups)
+}
+
+// TripleDipperProduct is the triple dipper, which is three appetizers.
+var TripleDipperProduct = Product{
+	ID:     "triple-dipper",
+	Name:   "Triple Dipper",
+	Path:   "/menu/appetizers/triple-dipper",
+	Groups: []string{"Selection 1", "Selection 2", "Selection 3"},
+}
+
+// Products are the Products that can be ordered.
+var Products = []Product{TripleDipperProduct}
+
+// FindProduct returns the Product with the given ID and whether there is one.
+func FindProduct(id string) (Product, bool) {
+	for _, p := range Products {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Product{}, false
+}
+
+// A Combo is a Product with its items selected.
+type Combo interface {
+	Product() Product
+	// ItemValues returns the selected items in the order of the Product's
+	// selection groups.
+	ItemValues() []Item
+}
+
+// comboForm checks if the Combo is permitted and returns a cart form with all
+// of its components' Chili's IDs and the given CSRF token.
+func comboForm(ids *optionIDs, csrf string, c Combo) (url.Values, error) {
+	form := url.Values{}
+	form.Add("_csrf", csrf)
+
+	for i, it := range c.ItemValues() {
+		iid, err := ids.itemID(it.String(), i)
+		if err != nil {
+			return nil, fmt.Errorf("adding Item to form: %w", err)
+		}
+		form.Add("selectedIds", iid)
+
+		for _, e := range it.ExtraValues() {
+			eid, err := ids.extraID(e, iid)
+			if err != nil {
+				return nil, fmt.Errorf("adding Extra to form: %w", err)
+			}
+			form.Add("selectedIds", eid)
+		}
+	}
+	return form, nil
+}
+
+// parseCart parses the response to a cart form.
+func parseCart(body []byte) error {
+	var decoded map[string]interface{}
+	err := json.Unmarshal(body, &decoded)
+	if err != nil || decoded == nil {
+		return errors.New("parsing cart response body")
+	}
+	_, ok := decoded["error"]
+	if ok {
+		return errors.New("can't add invalid item to cart")
+	}
+	return nil
+}
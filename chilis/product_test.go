@@ -0,0 +1,45 @@
+package chilis
+
+import "testing"
+
+var cartTests = []struct {
+	body string
+	ok   bool
+}{
+	{`{"cartCount":1}`, true},
+	{`{"error":"invalid item 1234","cartCount":0}`, false},
+	{`[]`, false},
+	{`null`, false},
+	{`<html></html>`, false},
+}
+
+func TestParseCart(t *testing.T) {
+	for _, test := range cartTests {
+		err := parseCart([]byte(test.body))
+		if (err == nil) != test.ok {
+			t.Errorf("parseCart(%s) = %v, want ok = %t", test.body, err, test.ok)
+		}
+	}
+}
+
+func FuzzParseCart(f *testing.F) {
+	for _, test := range cartTests {
+		f.Add([]byte(test.body))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		parseCart(b)
+	})
+}
+
+func TestFindProduct(t *testing.T) {
+	p, ok := FindProduct(TripleDipperProduct.ID)
+	if !ok || p.Path != TripleDipperProduct.Path {
+		t.Errorf("FindProduct(%s) = %+v, %t, want %+v", TripleDipperProduct.ID, p, ok, TripleDipperProduct)
+	}
+	if p.Selections() != 3 {
+		t.Errorf("selections = %d, want 3", p.Selections())
+	}
+	if _, ok := FindProduct("nachos"); ok {
+		t.Error("found product nachos")
+	}
+}
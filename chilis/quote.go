@@ -0,0 +1,70 @@
+package chilis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// A Quote is what Chili's offers for delivering an order to an address, found
+// without checking out.
+type Quote struct {
+	// Location is the location nearest to the address, which is the one that
+	// would deliver the order.
+	Location NearbyLocation
+	// InRange reports whether the address is in the location's delivery
+	// range. DeliveryTime is only set if it is.
+	InRange      bool
+	DeliveryTime time.Time
+}
+
+// Quote sets the Session's location to the location nearest to the given
+// address and returns a Quote for delivering an order to the address. No
+// customer information is submitted. A Quote has no prices, since items can
+// only be added to the cart once the location is set; use Prices to price the
+// cart after filling it.
+func (s *Session) Quote(ctx context.Context, addr Address) (Quote, error) {
+	var q Quote
+	doc, err := s.locationsPage(ctx, addr)
+	if err != nil {
+		return q, fmt.Errorf("quoting delivery: %v", err)
+	}
+	locs, err := parseLocations(doc)
+	if err != nil {
+		return q, s.snapshot(fmt.Errorf("quoting delivery: %w", err))
+	}
+	if err := locs[0].Available(); err != nil {
+		return q, err
+	}
+	q.Location = locs[0]
+	csrf, err := parseCSRFToken(doc)
+	if err != nil {
+		return q, s.snapshot(fmt.Errorf("quoting delivery: %w", err))
+	}
+	err = s.SetLocationByID(ctx, q.Location.ID)
+	if err != nil {
+		return q, fmt.Errorf("quoting delivery: %v", err)
+	}
+
+	q.DeliveryTime, err = s.deliveryTime(ctx, addr, csrf)
+	if errors.Is(err, ErrOutOfRange) {
+		return q, nil
+	}
+	if err != nil {
+		return q, fmt.Errorf("quoting delivery: %w", err)
+	}
+	q.InRange = true
+	return q, nil
+}
+
+// Prices returns the prices of a delivery order of the Session's cart,
+// including fees, from the checkout page. The cart must have items in it.
+func (s *Session) Prices(ctx context.Context) (OrderInfo, error) {
+	doc, err := s.parseCartPage(ctx, "/order/pickup")
+	if err != nil {
+		return OrderInfo{}, fmt.Errorf("fetching prices: %w", err)
+	}
+	info, err := parseInfo(doc)
+	return info, s.snapshot(err)
+}
@@ -0,0 +1,77 @@
+package chilis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+func TestSessionQuote(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	q, err := sess.Quote(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if q.Location.ID != "001.005.0945" {
+		t.Errorf("location ID = %s, want 001.005.0945", q.Location.ID)
+	}
+	if sess.LocationID != q.Location.ID {
+		t.Errorf("session location ID = %s, want %s", sess.LocationID, q.Location.ID)
+	}
+	if !q.InRange {
+		t.Error("address is out of range")
+	}
+	want, _ := time.Parse(time.RFC3339, "2021-03-03T21:37:43.258000Z")
+	if !q.DeliveryTime.Equal(want) {
+		t.Errorf("delivery time = %v, want %v", q.DeliveryTime, want)
+	}
+
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	info, err := sess.Prices(ctx)
+	if err != nil {
+		t.Fatalf("Prices: %v", err)
+	}
+	if info.DeliveryFee.String() != "$3.99" || info.ServiceFee.String() != "$3.25" {
+		t.Errorf("fees = %s, %s, want $3.99, $3.25", info.DeliveryFee, info.ServiceFee)
+	}
+
+	state, _ := srv.Session(sess.ID)
+	if state.Checkout != nil {
+		t.Errorf("checkout = %v, want nothing submitted", state.Checkout)
+	}
+}
+
+func TestSessionQuoteOutOfRange(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	srv.Fixtures.Estimate = "estimater1.json"
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	q, err := sess.Quote(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("Quote: %v", err)
+	}
+	if q.InRange {
+		t.Error("address is in range")
+	}
+	if !q.DeliveryTime.IsZero() {
+		t.Errorf("delivery time = %v, want zero", q.DeliveryTime)
+	}
+}
@@ -0,0 +1,130 @@
+package chilis
+
+import (
+	"fmt"
+
+	"github.com/antchfx/htmlquery"
+	"golang.org/x/net/html"
+)
+
+// A selector finds a field on a Chili's page. It holds every XPath query that
+// has been used to find the field as Chili's markup has changed, oldest first.
+// The newest query is tried first, and older ones are fallbacks for pages that
+// are still served with the old markup.
+//
+// Queries may contain fmt verbs, which are replaced with the arguments given
+// when the field is looked up.
+type selector struct {
+	page    string
+	field   string
+	queries []string
+}
+
+// A Match reports which version of a selector's query found a field on a page.
+// Versions are numbered from 1 in the order that they were added, so a Match
+// whose Version is less than Latest means Chili's served older markup than
+// expected.
+type Match struct {
+	Page    string
+	Field   string
+	Version int
+	Latest  int
+}
+
+// Fallback reports whether the field was found by a query other than the
+// newest one.
+func (m Match) Fallback() bool {
+	return m.Version < m.Latest
+}
+
+// OnMatch, if set, is called every time a field on a Chili's page is found.
+var OnMatch func(Match)
+
+// selectors is the registry of every selector used to scrape Chili's pages. The
+// tests use it to check that every query compiles and that every field is
+// identified by its page and name alone, since that's all a Match reports.
+var selectors []*selector
+
+// newSelector registers and returns a selector for the given field of the given
+// page that uses the given queries, oldest first.
+func newSelector(page, field string, queries ...string) *selector {
+	s := &selector{page, field, queries}
+	selectors = append(selectors, s)
+	return s
+}
+
+// query returns the given version of the selector's query with its verbs
+// replaced with the given arguments.
+func (s *selector) query(version int, args []interface{}) string {
+	q := s.queries[version-1]
+	if len(args) > 0 {
+		q = fmt.Sprintf(q, args...)
+	}
+	return q
+}
+
+// match reports that the given version of the selector's query found its
+// field.
+func (s *selector) match(version int) {
+	if OnMatch != nil {
+		OnMatch(Match{s.page, s.field, version, len(s.queries)})
+	}
+}
+
+// invalid returns an error for the given version of the selector's query
+// failing to compile.
+func (s *selector) invalid(version int, err error) error {
+	return fmt.Errorf("parsing %s page: invalid query %d for %s: %v", s.page, version, s.field, err)
+}
+
+// find finds and returns every HTML element that matches the newest of the
+// selector's queries that matches anything. It returns a ParseError if none of
+// them do.
+func (s *selector) find(node *html.Node, args ...interface{}) ([]*html.Node, error) {
+	for v := len(s.queries); v > 0; v-- {
+		elts, err := htmlquery.QueryAll(node, s.query(v, args))
+		if err != nil {
+			return nil, s.invalid(v, err)
+		}
+		if len(elts) > 0 {
+			s.match(v)
+			return elts, nil
+		}
+	}
+	return nil, &ParseError{s.page, s.field}
+}
+
+// findOne is the same as find, but it only finds the first HTML element.
+func (s *selector) findOne(node *html.Node, args ...interface{}) (*html.Node, error) {
+	for v := len(s.queries); v > 0; v-- {
+		elt, err := htmlquery.Query(node, s.query(v, args))
+		if err != nil {
+			return nil, s.invalid(v, err)
+		}
+		if elt != nil {
+			s.match(v)
+			return elt, nil
+		}
+	}
+	return nil, &ParseError{s.page, s.field}
+}
+
+// innerText finds the first HTML element that the selector matches and returns
+// its inner text.
+func (s *selector) innerText(node *html.Node, args ...interface{}) (string, error) {
+	elt, err := s.findOne(node, args...)
+	if err != nil {
+		return "", err
+	}
+	return htmlquery.InnerText(elt), nil
+}
+
+// selectAttr finds the first HTML element that the selector matches and
+// returns the value of the given attribute.
+func (s *selector) selectAttr(node *html.Node, attr string, args ...interface{}) (string, error) {
+	elt, err := s.findOne(node, args...)
+	if err != nil {
+		return "", err
+	}
+	return htmlquery.SelectAttr(elt, attr), nil
+}
@@ -0,0 +1,99 @@
+package chilis
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+)
+
+const selectorPage = `<html><body>
+<div class="cost old-subtotal">$13.19</div>
+<label>Choice 2</label>
+</body></html>`
+
+func TestSelectorFallback(t *testing.T) {
+	doc, err := htmlquery.Parse(strings.NewReader(selectorPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel := &selector{"checkout", "subtotal", []string{
+		classQuery("div", "cost old-subtotal"),
+		classQuery("div", "cost js-subtotal"),
+	}}
+
+	var matches []Match
+	OnMatch = func(m Match) { matches = append(matches, m) }
+	defer func() { OnMatch = nil }()
+
+	text, err := sel.innerText(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "$13.19" {
+		t.Errorf("text = %s, want $13.19", text)
+	}
+	want := Match{"checkout", "subtotal", 1, 2}
+	if len(matches) != 1 || matches[0] != want {
+		t.Fatalf("matches = %v, want [%v]", matches, want)
+	}
+	if !matches[0].Fallback() {
+		t.Error("match isn't a fallback")
+	}
+}
+
+func TestSelectorArgs(t *testing.T) {
+	doc, err := htmlquery.Parse(strings.NewReader(selectorPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sel := &selector{"menu", "item selection", []string{
+		"//label[text()='Selection %d']",
+		"//label[text()='Choice %d']",
+	}}
+	if _, err := sel.findOne(doc, 2); err != nil {
+		t.Error(err)
+	}
+	_, err = sel.findOne(doc, 3)
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want ParseError", err)
+	}
+	if pe.Page != "menu" || pe.Field != "item selection" {
+		t.Errorf("err = %+v, want menu item selection", pe)
+	}
+}
+
+func TestParseErrorField(t *testing.T) {
+	_, err := parseInfo(dipperDocs[0])
+	var pe *ParseError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %v, want ParseError", err)
+	}
+	if pe.Page != "checkout" || pe.Field != "subtotal" {
+		t.Errorf("err = %+v, want checkout subtotal", pe)
+	}
+}
+
+func TestSelectorsCompile(t *testing.T) {
+	doc, err := htmlquery.Parse(strings.NewReader(selectorPage))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Queries are compiled with their verbs replaced by plausible arguments.
+	args := strings.NewReplacer("%s", "x", "%d", "1")
+	fields := make(map[[2]string]bool)
+	for _, sel := range selectors {
+		name := sel.page + " " + sel.field
+		if fields[[2]string{sel.page, sel.field}] {
+			t.Errorf("%s has more than one selector", name)
+		}
+		fields[[2]string{sel.page, sel.field}] = true
+		for v, q := range sel.queries {
+			if _, err := htmlquery.QueryAll(doc, args.Replace(q)); err != nil {
+				t.Errorf("%s: query %d: %v", name, v+1, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package chilis
+
+// The selectors for every field scraped from Chili's pages, grouped by page.
+// When Chili's changes its markup, add the new query to the end of the field's
+// selector rather than replacing the old one.
+
+// Any page
+var (
+	selHeaderCart = newSelector("header", "cart", attrQuery("a", "id", "header-cart"))
+	selCSRFToken  = newSelector("form", "CSRF token", attrQuery("input", "name", "_csrf"))
+	selFieldError = newSelector("form", "field error", classQuery("span", "field-error"))
+	selFormError  = newSelector("form", "form error", "//div[contains(@class, 'form-errors')]")
+)
+
+// Product menu page
+var (
+	selMenuDescription = newSelector("menu", "description", classQuery("div", "detail-description"))
+	selItemLabel       = newSelector("menu", "item selection", "//label[text()='%s']")
+	selItemOptions     = newSelector("menu", "item option", "//option")
+	selExtraGroups     = newSelector("menu", "extra group", "//div[@data-related]")
+	selExtraOptions    = newSelector("menu", "extra option", "//option")
+)
+
+// Menu item page
+var (
+	selMenuItemOptions = newSelector("menu item", "option", "//option")
+)
+
+// Location search page
+var (
+	selLocations          = newSelector("location search", "location", classQuery("div", "location"))
+	selNearbyName         = newSelector("location search", "name", classQuery("span", "location-title"))
+	selNearbyPhone        = newSelector("location search", "phone", classQuery("span", "tel"))
+	selNearbyStreet       = newSelector("location search", "street", classQuery("span", "street-address"))
+	selNearbyCity         = newSelector("location search", "city", classQuery("span", "locality"))
+	selNearbyState        = newSelector("location search", "state", classQuery("span", "region"))
+	selNearbyZip          = newSelector("location search", "zip", classQuery("span", "postal-code"))
+	selNearbyDistance     = newSelector("location search", "distance", classQuery("span", "location-distance"))
+	selNearbyOrderNow     = newSelector("location search", "order button", "//a[@class='btn slim order-btn' and text()='Order Now']")
+	selNearbyDeliveryIcon = newSelector("location search", "delivery icon", classQuery("span", "delivery icon-doordash"))
+)
+
+// Cart page
+var (
+	selCartLines      = newSelector("cart", "line", "//div[contains(@class, 'cart-line')]")
+	selCartLineID     = newSelector("cart", "line ID", attrQuery("input", "name", "lineItemId"))
+	selCartLineName   = newSelector("cart", "line name", classQuery("div", "item-info"))
+	selCartLineQty    = newSelector("cart", "line quantity", classQuery("div", "qty-info"))
+	selCartLinePrice  = newSelector("cart", "line price", classQuery("div", "cost-info")+"/div")
+	selCartLineChoice = newSelector("cart", "line choice", classQuery("div", "choice-list")+"//li")
+)
+
+// Checkout page
+var (
+	selSubtotal      = newSelector("checkout", "subtotal", classQuery("div", "cost js-subtotal"))
+	selPickupTax     = newSelector("checkout", "pickup tax", "//tr[@id='pickup-tax-payment']/td[2]/div[@class='cost']")
+	selDeliveryTax   = newSelector("checkout", "delivery tax", "//tr[@id='delivery-tax']/td[2]/div[@class='cost']")
+	selDeliveryFee   = newSelector("checkout", "delivery fee", "//tr[@id='delivery-fee']/td[2]/div[@class='cost']")
+	selServiceCharge = newSelector("checkout", "service charge", "//tr[@id='service-charge']/td[2]/div[@class='cost']")
+	selTransactionID = newSelector("checkout", "transaction ID", attrQuery("input", "id", "transactionId"))
+	selTimeGroup     = newSelector("checkout", "time group", attrQuery("div", "id", "%s-time-group"))
+	selSlotDates     = newSelector("checkout", "dates", "//select[@id='%s-date']/option")
+	selSlotTimes     = newSelector("checkout", "times", "//select[@id='%s-time']/option")
+)
+
+// Order confirmation page
+var (
+	selConfirmedLocation = newSelector("confirmation", "location", classQuery("div", "location-address-wrapper"))
+	selConfirmedName     = newSelector("confirmation", "location name", classQuery("div", "location-name"))
+	selConfirmedPhone    = newSelector("confirmation", "location phone", classQuery("a", "location-phone tel"))
+	selConfirmedStreet   = newSelector("confirmation", "location street", classQuery("div", "location-address-street"))
+	selConfirmedCity     = newSelector("confirmation", "location city", classQuery("span", "location-address-city"))
+	selConfirmedState    = newSelector("confirmation", "location state", classQuery("span", "location-address-state"))
+	selConfirmedZip      = newSelector("confirmation", "location zip", classQuery("span", "location-address-zip"))
+	selRegisterLink      = newSelector("confirmation", "register link", attrQuery("a", "id", "order-confirmation-register"))
+	selTrackingLink      = newSelector("confirmation", "tracking link", "//a[contains(@class, 'tracking-btn')]")
+)
@@ -1,6 +1,8 @@
 package chilis
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -16,165 +18,335 @@ import (
 type Session struct {
 	ID     string
 	Client *http.Client
+	// LocationID is the restaurant ID of the Session's location, if it has
+	// been set.
+	LocationID string
+	// base is the URL that every path requested by the Session is relative
+	// to.
+	base *url.URL
+	// rec keeps the latest response so that it can be saved if it can't be
+	// parsed. It's nil unless the Session's Client has a SnapshotDir.
+	rec *recorder
+	// menus is the cache of item and extra IDs shared by the Sessions of the
+	// Session's Client.
+	menus *menuCache
+	// csrf is the Session's CSRF token, if it's been seen on a page. It's
+	// used to add TripleDippers to the cart without fetching a page for it.
+	csrf string
 }
 
-// NewSession returns a pointer to a new Session given a session ID.
+// NewSession returns a pointer to a new Session given a session ID. It uses
+// DefaultClient.
 func NewSession(id string) (*Session, error) {
-	var sess *Session
-	cook := http.Cookie{Name: "SESSION", Value: id}
-	clt, err := createClient(&cook)
-	if err != nil {
-		return sess, fmt.Errorf("creating session: %v", err)
-	}
+	return DefaultClient.NewSession(id)
+}
 
-	return &Session{id, clt}, err
+// StartSession returns a pointer to a new Session. It uses DefaultClient.
+func StartSession(ctx context.Context) (*Session, error) {
+	return DefaultClient.StartSession(ctx)
 }
 
-// StartSession returns a pointer to a new Session.
-func StartSession() (*Session, error) {
-	jar, err := createJar()
-	if err != nil {
-		return nil, fmt.Errorf("starting session: %v", err)
-	}
-	clt := &http.Client{Jar: jar}
-	resp, err := clt.Get("https://www.chilis.com")
-	if err != nil {
-		return nil, fmt.Errorf("starting session: %v", err)
-	}
-	resp.Body.Close()
+// RestoreSession returns a pointer to the Session exported as the given blob.
+// It uses DefaultClient.
+func RestoreSession(blob []byte) (*Session, error) {
+	return DefaultClient.RestoreSession(blob)
+}
 
-	var id string
-	for _, cook := range resp.Cookies() {
-		if cook.Name == "SESSION" {
-			id = cook.Value
-		}
+// exportedSession is the format of an exported Session.
+type exportedSession struct {
+	ID         string         `json:"id"`
+	LocationID string         `json:"locationId"`
+	Cookies    []storedCookie `json:"cookies"`
+}
+
+// Export returns a blob containing the Session's ID, its location ID, and every
+// cookie in its jar. The Session can be resumed with RestoreSession.
+func (s *Session) Export() ([]byte, error) {
+	jar, ok := s.Client.Jar.(*recordingJar)
+	if !ok {
+		return nil, errors.New("exporting session: session's cookie jar can't be exported")
 	}
-	if id == "" {
-		return nil, errors.New("failed to find session cookie")
+	blob, err := json.Marshal(exportedSession{
+		ID:         s.ID,
+		LocationID: s.LocationID,
+		Cookies:    jar.stored(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("exporting session: %v", err)
 	}
-	return &Session{id, clt}, err
+	return blob, nil
 }
 
-// SetLocation sets the Chili's location for the Session.
-func (s *Session) SetLocation(addr Address) error {
-	clt := s.Client
-	id, err := nearestLocationID(clt, addr)
+// SetLocation sets the Chili's location for the Session to the location
+// nearest to the given address.
+func (s *Session) SetLocation(ctx context.Context, addr Address) error {
+	id, err := s.nearestLocationID(ctx, addr)
 	if err != nil {
-		return fmt.Errorf("setting location: %v", err)
+		return fmt.Errorf("setting location: %w", err)
 	}
-	u := fmt.Sprintf("https://www.chilis.com/order?rid=%s", id)
+	return s.SetLocationByID(ctx, id)
+}
 
-	resp, err := clt.Get(u)
+// SetLocationByID sets the Chili's location for the Session to the location
+// with the given restaurant ID.
+func (s *Session) SetLocationByID(ctx context.Context, id string) error {
+	resp, err := s.get(ctx, "/order?rid="+url.QueryEscape(id))
 	if err != nil {
 		return fmt.Errorf("setting location: %v", err)
 	}
 	resp.Body.Close()
 
+	// Chili's starts a new session if the Session's has expired.
+	if sid, err := sessionID(s.Client, s.base); err == nil {
+		if sid != s.ID {
+			s.csrf = ""
+		}
+		s.ID = sid
+	}
+	s.LocationID = id
 	return nil
 }
 
+// Locations returns every location in proximity of the given address, nearest
+// first.
+func (s *Session) Locations(ctx context.Context, addr Address) ([]NearbyLocation, error) {
+	doc, err := s.locationsPage(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	locs, err := parseLocations(doc)
+	return locs, s.snapshot(err)
+}
+
 // nearestLocationID returns the ID of the nearest location that is in proximity
 // of the given address.
-func nearestLocationID(clt *http.Client, addr Address) (string, error) {
-	var id string
-
-	u, err := url.Parse("https://www.chilis.com/locations/results")
+func (s *Session) nearestLocationID(ctx context.Context, addr Address) (string, error) {
+	doc, err := s.locationsPage(ctx, addr)
 	if err != nil {
-		return id, fmt.Errorf("parsing location URL: %v", err)
+		return "", err
 	}
+	id, err := parseNearestID(doc)
+	return id, s.snapshot(err)
+}
+
+// locationsPage returns the root node of the location search page for the
+// given address.
+func (s *Session) locationsPage(ctx context.Context, addr Address) (*html.Node, error) {
 	query := url.Values{
 		"query": []string{addr.String()},
 	}
-	u.RawQuery = query.Encode()
-
-	resp, err := clt.Get(u.String())
+	resp, err := s.get(ctx, "/locations/results?"+query.Encode())
 	if err != nil {
-		return id, fmt.Errorf("fetching location: %v", err)
+		return nil, fmt.Errorf("fetching location: %v", err)
 	}
 	defer resp.Body.Close()
 
 	doc, err := html.Parse(resp.Body)
 	if err != nil {
-		return id, fmt.Errorf("parsing locations html: %v", err)
+		return nil, fmt.Errorf("parsing locations html: %v", err)
+	}
+	if csrf, err := parseCSRFToken(doc); err == nil {
+		s.csrf = csrf
 	}
-	return parseNearestID(doc)
+	return doc, nil
 }
 
-// Cart adds the given TripleDipper to the Session's cart.
-func (s *Session) Cart(td TripleDipper) error {
-	clt := s.Client
-	u := "https://www.chilis.com/menu/appetizers/triple-dipper"
-	doc, err := parsePage(clt, u)
-	if err != nil {
-		return fmt.Errorf("adding TripleDipper to cart: %v", err)
+// Cart adds the given Combo to the Session's cart. The IDs of the items and
+// extras on its Product's menu page are cached for the Session's location, so
+// the page is only fetched if they aren't cached yet, one of them isn't found
+// in the cache, or Chili's rejects the Session's CSRF token.
+func (s *Session) Cart(ctx context.Context, c Combo) error {
+	p := c.Product()
+	ids := s.menus.get(s.LocationID, p.ID)
+	cached := ids != nil && s.csrf != ""
+	for {
+		if !cached {
+			var err error
+			ids, err = s.menuIDs(ctx, p)
+			if err != nil {
+				return fmt.Errorf("adding %s to cart: %w", p.Name, err)
+			}
+		}
+
+		form, err := comboForm(ids, s.csrf, c)
+		if err != nil && cached {
+			// The menu may have changed since it was cached.
+			s.menus.invalidate(s.LocationID, p.ID)
+			cached = false
+			continue
+		}
+		if err != nil {
+			return s.snapshot(fmt.Errorf("adding %s to cart: %w", p.Name, err))
+		}
+
+		resp, err := s.postForm(ctx, p.Path, form)
+		if err != nil {
+			return fmt.Errorf("posting cart request: %v", err)
+		}
+		if resp.StatusCode == http.StatusForbidden && cached {
+			// The CSRF token is no longer valid if the session expired.
+			resp.Body.Close()
+			cached = false
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("reading cart response body: %v", err)
+		}
+		return s.snapshot(parseCart(body))
 	}
+}
 
-	form, err := tripleDipperForm(doc, td)
+// menuIDs fetches the given Product's menu page and returns the IDs of its
+// items and extras, caching them for the Session's location. The page's CSRF
+// token becomes the Session's.
+func (s *Session) menuIDs(ctx context.Context, p Product) (*optionIDs, error) {
+	doc, err := s.parsePage(ctx, p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("fetching %s page: %v", p.Name, err)
+	}
+	csrf, err := parseCSRFToken(doc)
 	if err != nil {
-		return fmt.Errorf("adding TripleDipper to cart: %w", err)
+		return nil, s.snapshot(fmt.Errorf("parsing %s page: %w", p.Name, err))
 	}
+	ids, err := parseOptionIDs(doc, p)
+	if err != nil {
+		return nil, s.snapshot(fmt.Errorf("parsing %s page: %w", p.Name, err))
+	}
+	s.csrf = csrf
+	s.menus.put(s.LocationID, p.ID, ids)
+	return ids, nil
+}
 
-	resp, err := clt.PostForm(u, form)
+// DeliverySlots returns the delivery slots offered for the Session's cart.
+func (s *Session) DeliverySlots(ctx context.Context) ([]DeliverySlot, error) {
+	doc, err := s.parseCartPage(ctx, "/order/pickup")
 	if err != nil {
-		return fmt.Errorf("posting cart request: %v", err)
+		return nil, fmt.Errorf("fetching delivery information: %w", err)
 	}
-	defer resp.Body.Close()
+	slots, err := parseDeliverySlots(doc)
+	return slots, s.snapshot(err)
+}
 
-	body, err := io.ReadAll(resp.Body)
+// PickupSlots returns the pickup slots offered for the Session's cart.
+func (s *Session) PickupSlots(ctx context.Context) ([]DeliverySlot, error) {
+	doc, err := s.parseCartPage(ctx, "/order/pickup")
 	if err != nil {
-		return fmt.Errorf("reading cart response body: %v", err)
+		return nil, fmt.Errorf("fetching pickup information: %w", err)
 	}
-	return parseCart(body)
+	slots, err := parseSlots(doc, Pickup)
+	return slots, s.snapshot(err)
 }
 
 // Checkout submits the given Customer's information to the Session and returns
-// an OrderInfo struct.
-func (s *Session) Checkout(c Customer, addr Address) (OrderInfo, error) {
+// an OrderInfo struct. The order is delivered at the delivery slot with the
+// given time value or, if it's empty, as soon as possible.
+func (s *Session) Checkout(ctx context.Context, c Customer, addr Address, deliverAt string) (OrderInfo, error) {
 	var info OrderInfo
-	clt := s.Client
 	if err := validCustomer(c); err != nil {
 		return info, err
 	}
 
-	u := "https://www.chilis.com/order/pickup"
-	doc, err := parsePage(clt, u)
+	p := "/order/pickup"
+	doc, err := s.parseCartPage(ctx, p)
 	if err != nil {
-		return info, fmt.Errorf("fetching delivery information: %v", err)
+		return info, fmt.Errorf("fetching delivery information: %w", err)
 	}
 
-	form, err := checkoutForm(doc, c, addr)
+	slots, err := parseDeliverySlots(doc)
+	if err != nil {
+		return info, s.snapshot(fmt.Errorf("building checkout request: %w", err))
+	}
+	slot, err := findSlot(slots, deliverAt)
 	if err != nil {
-		return info, fmt.Errorf("building checkout request: %w", err)
+		return info, err
+	}
+	form, err := checkoutForm(doc, c, Delivery, addr, slot)
+	if err != nil {
+		return info, s.snapshot(fmt.Errorf("building checkout request: %w", err))
 	}
 
 	info, err = parseInfo(doc)
 	if err != nil {
-		return info, fmt.Errorf("parsing order total: %v", err)
+		return info, s.snapshot(fmt.Errorf("parsing order total: %w", err))
+	}
+
+	info.Slot = slot
+	info.DeliveryTime, err = s.deliveryTime(ctx, addr, form.Get("_csrf"))
+	if err != nil {
+		return info, fmt.Errorf("fetching delivery estimate: %w", err)
+	}
+
+	resp, err := s.postForm(ctx, p, form)
+	if err != nil {
+		return info, fmt.Errorf("posting checkout request: %v", err)
+	}
+	defer resp.Body.Close()
+	err = s.verifyCheckout(resp)
+	if err != nil {
+		return info, err
+	}
+
+	return info, nil
+}
+
+// CheckoutPickup submits the given Customer's information to the Session for a
+// pickup order and returns an OrderInfo struct. The order is ready at the
+// pickup slot with the given time value or, if it's empty, as soon as
+// possible. Pickup orders have no delivery fee or delivery estimate.
+func (s *Session) CheckoutPickup(ctx context.Context, c Customer, pickupAt string) (OrderInfo, error) {
+	var info OrderInfo
+	if err := validCustomer(c); err != nil {
+		return info, err
+	}
+
+	p := "/order/pickup"
+	doc, err := s.parseCartPage(ctx, p)
+	if err != nil {
+		return info, fmt.Errorf("fetching pickup information: %w", err)
+	}
+
+	slots, err := parseSlots(doc, Pickup)
+	if err != nil {
+		return info, s.snapshot(fmt.Errorf("building checkout request: %w", err))
+	}
+	slot, err := findSlot(slots, pickupAt)
+	if err != nil {
+		return info, BadRequestError{"pickup time"}
+	}
+	form, err := checkoutForm(doc, c, Pickup, Address{}, slot)
+	if err != nil {
+		return info, s.snapshot(fmt.Errorf("building checkout request: %w", err))
 	}
 
-	info.DeliveryTime, err = s.deliveryTime(addr, form.Get("_csrf"))
+	info, err = parsePickupInfo(doc)
 	if err != nil {
-		return info, fmt.Errorf("parsing order total: %v", err)
+		return info, s.snapshot(fmt.Errorf("parsing order total: %w", err))
 	}
+	info.Slot = slot
 
-	_, err = clt.PostForm(u, form)
+	resp, err := s.postForm(ctx, p, form)
 	if err != nil {
 		return info, fmt.Errorf("posting checkout request: %v", err)
 	}
+	defer resp.Body.Close()
+	err = s.verifyCheckout(resp)
+	if err != nil {
+		return info, err
+	}
 
 	return info, nil
 }
 
 // deliveryTime returns an estimated delivery time or an error if the Customer's
 // address is out of range.
-func (s *Session) deliveryTime(addr Address, csrf string) (time.Time, error) {
+func (s *Session) deliveryTime(ctx context.Context, addr Address, csrf string) (time.Time, error) {
 	var t time.Time
-	clt := s.Client
-	u := "https://www.chilis.com/order/delivery/estimate"
 	form := url.Values{}
 	form.Add("_csrf", csrf)
 	form.Add("deliveryAddress", addr.String())
-	resp, err := clt.PostForm(u, form)
+	resp, err := s.postForm(ctx, "/order/delivery/estimate", form)
 	if err != nil {
 		return t, fmt.Errorf("fetching delivery estimate: %v", err)
 	}
@@ -184,28 +356,28 @@ func (s *Session) deliveryTime(addr Address, csrf string) (time.Time, error) {
 		return t, fmt.Errorf("reading delivery estimate response: %v", err)
 	}
 
-	return parseEstimate(body)
+	t, err = parseEstimate(body)
+	return t, s.snapshot(err)
 }
 
-// Order places the order using the given PaymentMethod and returns the
-// Location at which the order was placed.
-func (s *Session) Order(pm *PaymentMethod) (string, error) {
-	var loc string
-	clt := s.Client
-	u := "https://www.chilis.com/order/payment"
+// Order places the order, which was checked out in the given mode, using the
+// given PaymentMethod and returns the Location at which the order was placed.
+func (s *Session) Order(ctx context.Context, pm *PaymentMethod, mode OrderMode) (Location, error) {
+	var loc Location
+	p := "/order/payment"
 
 	if err := pm.validate(); err != nil {
 		return loc, fmt.Errorf("creating order: %w", err)
 	}
-	doc, err := parsePage(clt, u)
+	doc, err := s.parseCartPage(ctx, p)
 	if err != nil {
-		return loc, fmt.Errorf("fetching payment information: %v", err)
+		return loc, fmt.Errorf("fetching payment information: %w", err)
 	}
-	form, err := pm.form(doc)
+	form, err := pm.form(doc, mode)
 	if err != nil {
-		return loc, fmt.Errorf("bulding order request: %v", err)
+		return loc, s.snapshot(fmt.Errorf("bulding order request: %v", err))
 	}
-	resp, err := clt.PostForm(u, form)
+	resp, err := s.postForm(ctx, p, form)
 	if err != nil {
 		return loc, fmt.Errorf("posting order request: %v", err)
 	}
@@ -214,5 +386,33 @@ func (s *Session) Order(pm *PaymentMethod) (string, error) {
 	if err != nil {
 		return loc, fmt.Errorf("parsing order response: %v", err)
 	}
-	return parseLocation(doc)
+	if err := parseFormError(doc); err != nil {
+		return loc, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return loc, s.snapshot(fmt.Errorf("placing order: unexpected response %s", resp.Status))
+	}
+	loc, err = parseLocation(doc)
+	return loc, s.snapshot(err)
+}
+
+// verifyCheckout returns an error if the given response to a checkout request
+// shows that Chili's didn't accept it. Accepted checkouts are redirected to the
+// payment page.
+func (s *Session) verifyCheckout(resp *http.Response) error {
+	path := resp.Request.URL.Path
+	if path == s.base.Path+"/order/payment" && resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	doc, err := html.Parse(resp.Body)
+	if err != nil {
+		return fmt.Errorf("parsing checkout response: %v", err)
+	}
+	if err := parseFormError(doc); err != nil {
+		return err
+	}
+	if path != s.base.Path+"/order/pickup" {
+		return ErrSessionExpired
+	}
+	return s.snapshot(fmt.Errorf("checking out: unexpected response %s", resp.Status))
 }
@@ -0,0 +1,515 @@
+package chilis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+// testItem is a triple dipper item used in tests.
+type testItem struct {
+	value  string
+	extras []string
+}
+
+func (it testItem) String() string {
+	return it.value
+}
+
+func (it testItem) ExtraValues() []string {
+	return it.extras
+}
+
+// testDipper is a triple dipper used in tests.
+type testDipper []Item
+
+func (td testDipper) Product() Product {
+	return TripleDipperProduct
+}
+
+func (td testDipper) ItemValues() []Item {
+	return td
+}
+
+var testCustomer = Customer{
+	FirstName: "Jane",
+	LastName:  "Doe",
+	Phone:     "9195550123",
+	Email:     "jane@example.com",
+}
+
+var testAddress = Address{
+	Street: "4600 Chapel Hill Blvd.",
+	City:   "Durham",
+	State:  "NC",
+	Zip:    "27707",
+}
+
+var testTripleDipper = testDipper{
+	testItem{"Awesome Blossom Petals", []string{"Ranch Dressing"}},
+	testItem{"Big Mouth® Bites", nil},
+	testItem{"Boneless Buffalo Wings", []string{"Bleu Cheese Dressing"}},
+}
+
+// testPaymentMethod returns a new PaymentMethod with a valid test card.
+func testPaymentMethod() *PaymentMethod {
+	return &PaymentMethod{
+		Number: "4111111111111111",
+		CVV:    "123",
+		Month:  "12",
+		Year:   "2099",
+		Name:   "Jane Doe",
+		Zip:    "27707",
+	}
+}
+
+func TestSessionOrder(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	if _, err := sess.Checkout(ctx, testCustomer, testAddress, ""); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+
+	// Resume the session like a server would between requests.
+	blob, err := sess.Export()
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	sess, err = c.RestoreSession(blob)
+	if err != nil {
+		t.Fatalf("RestoreSession: %v", err)
+	}
+	if sess.LocationID != "001.005.0945" {
+		t.Errorf("restored location ID = %s, want 001.005.0945", sess.LocationID)
+	}
+	loc, err := sess.Order(ctx, testPaymentMethod(), Delivery)
+	if err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+	if loc.Name != "Durham 15/501" {
+		t.Errorf("location name = %s, want Durham 15/501", loc.Name)
+	}
+
+	state, ok := srv.Session(sess.ID)
+	if !ok {
+		t.Fatalf("session %s not found on server", sess.ID)
+	}
+	if state.LocationID != "001.005.0945" {
+		t.Errorf("location ID = %s, want 001.005.0945", state.LocationID)
+	}
+	want := []string{"1569900708", "1569901057", "285722857", "285722881", "285726260"}
+	if len(state.Cart) != 1 || !equal(state.Cart[0].SelectedIDs, want) {
+		t.Errorf("cart = %v, want [%v]", state.Cart, want)
+	}
+	if state.Checkout.Get("email") != testCustomer.Email {
+		t.Errorf("checkout email = %s, want %s", state.Checkout.Get("email"), testCustomer.Email)
+	}
+	if state.Payment.Get("cardNumber") != "4111-1111-1111-1111" {
+		t.Errorf("card number = %s, want 4111-1111-1111-1111", state.Payment.Get("cardNumber"))
+	}
+}
+
+func TestSessionCheckoutScheduled(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	slots, err := sess.DeliverySlots(ctx)
+	if err != nil {
+		t.Fatalf("DeliverySlots: %v", err)
+	}
+	slot := slots[len(slots)-1]
+	info, err := sess.Checkout(ctx, testCustomer, testAddress, slot.Time)
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if info.Slot != slot {
+		t.Errorf("slot = %+v, want %+v", info.Slot, slot)
+	}
+	state, _ := srv.Session(sess.ID)
+	if got := state.Checkout.Get("deliveryTime"); got != slot.Time {
+		t.Errorf("deliveryTime = %s, want %s", got, slot.Time)
+	}
+	if got := state.Checkout.Get("deliveryDate"); got != slot.Date {
+		t.Errorf("deliveryDate = %s, want %s", got, slot.Date)
+	}
+}
+
+func TestSessionCheckoutPickup(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocationByID(ctx, "001.005.0945"); err != nil {
+		t.Fatalf("SetLocationByID: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	slots, err := sess.PickupSlots(ctx)
+	if err != nil {
+		t.Fatalf("PickupSlots: %v", err)
+	}
+	slot := slots[1]
+	info, err := sess.CheckoutPickup(ctx, testCustomer, slot.Time)
+	if err != nil {
+		t.Fatalf("CheckoutPickup: %v", err)
+	}
+	if info.DeliveryFee != 0 || !info.DeliveryTime.IsZero() {
+		t.Errorf("info = %+v, want no delivery fee or time", info)
+	}
+	if _, err := sess.Order(ctx, testPaymentMethod(), Pickup); err != nil {
+		t.Fatalf("Order: %v", err)
+	}
+
+	state, _ := srv.Session(sess.ID)
+	if got := state.Checkout.Get("orderMode"); got != "pickup" {
+		t.Errorf("checkout orderMode = %s, want pickup", got)
+	}
+	if got := state.Checkout.Get("deliveryAddress"); got != "" {
+		t.Errorf("deliveryAddress = %s, want none", got)
+	}
+	if got := state.Checkout.Get("pickupTime"); got != slot.Time {
+		t.Errorf("pickupTime = %s, want %s", got, slot.Time)
+	}
+	if got := state.Payment.Get("orderMode"); got != "pickup" {
+		t.Errorf("payment orderMode = %s, want pickup", got)
+	}
+}
+
+func TestSessionSetLocationByID(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	locs, err := sess.Locations(ctx, testAddress)
+	if err != nil {
+		t.Fatalf("Locations: %v", err)
+	}
+	id := locs[1].ID
+	if err := sess.SetLocationByID(ctx, id); err != nil {
+		t.Fatalf("SetLocationByID: %v", err)
+	}
+	state, _ := srv.Session(sess.ID)
+	if state.LocationID != id {
+		t.Errorf("location ID = %s, want %s", state.LocationID, id)
+	}
+}
+
+func TestSessionCartInvalid(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	p := "/menu/appetizers/triple-dipper"
+	doc, err := sess.parsePage(ctx, p)
+	if err != nil {
+		t.Fatalf("parsePage: %v", err)
+	}
+	csrf, err := parseCSRFToken(doc)
+	if err != nil {
+		t.Fatalf("parseCSRFToken: %v", err)
+	}
+	form := url.Values{"_csrf": {csrf}, "selectedIds": {"1234"}}
+	resp, err := sess.postForm(ctx, p, form)
+	if err != nil {
+		t.Fatalf("postForm: %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if err := parseCart(body); err == nil {
+		t.Errorf("err = nil, want error for invalid item")
+	}
+}
+
+func TestSessionStaleCSRF(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	// A fresh session has a new CSRF token, so the old page's token must be
+	// rejected.
+	other, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	doc, err := other.parsePage(ctx, "/menu/appetizers/triple-dipper")
+	if err != nil {
+		t.Fatalf("parsePage: %v", err)
+	}
+	csrf, err := parseCSRFToken(doc)
+	if err != nil {
+		t.Fatalf("parseCSRFToken: %v", err)
+	}
+	ids, err := parseOptionIDs(doc, TripleDipperProduct)
+	if err != nil {
+		t.Fatalf("parseOptionIDs: %v", err)
+	}
+	form, err := comboForm(ids, csrf, testTripleDipper)
+	if err != nil {
+		t.Fatalf("comboForm: %v", err)
+	}
+	resp, err := sess.postForm(ctx, "/menu/appetizers/triple-dipper", form)
+	if err != nil {
+		t.Fatalf("postForm: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 403 {
+		t.Errorf("status = %d, want 403", resp.StatusCode)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	if _, err := sess.Checkout(ctx, testCustomer, testAddress, ""); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+
+	srv.Expire(sess.ID)
+	_, err = sess.Order(ctx, testPaymentMethod(), Delivery)
+	if !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("err = %v, want %v", err, ErrSessionExpired)
+	}
+	if _, err := sess.DeliverySlots(ctx); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("DeliverySlots err = %v, want %v", err, ErrSessionExpired)
+	}
+}
+
+func TestCartHasItems(t *testing.T) {
+	tests := map[string]bool{
+		"testdata/checkout1.html": true,
+		"testdata/dipper1.html":   false,
+		"testdata/location1.html": false,
+	}
+	for path, want := range tests {
+		doc, err := htmlquery.LoadDoc(path)
+		if err != nil {
+			log.Fatalf("loading HTML document: %v", err)
+		}
+		if got := cartHasItems(doc); got != want {
+			t.Errorf("cartHasItems(%s) = %t, want %t", path, got, want)
+		}
+	}
+}
+
+func TestSessionCartContents(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := sess.Cart(ctx, testTripleDipper); err != nil {
+			t.Fatalf("Cart: %v", err)
+		}
+	}
+	lines, err := sess.CartContents(ctx)
+	if err != nil {
+		t.Fatalf("CartContents: %v", err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("len(lines) = %d, want 2", len(lines))
+	}
+	for _, l := range lines {
+		if !l.Matches(testTripleDipper) {
+			t.Errorf("line %+v doesn't match %v", l, testTripleDipper)
+		}
+	}
+
+	if err := sess.RemoveLine(ctx, lines[0].ID); err != nil {
+		t.Fatalf("RemoveLine: %v", err)
+	}
+	state, _ := srv.Session(sess.ID)
+	if len(state.Cart) != 1 || state.Cart[0].ID != lines[1].ID {
+		t.Errorf("cart = %v, want line %s", state.Cart, lines[1].ID)
+	}
+	if err := sess.RemoveLine(ctx, lines[0].ID); err == nil {
+		t.Errorf("RemoveLine of removed line: err = nil, want error")
+	}
+
+	if err := sess.ClearCart(ctx); err != nil {
+		t.Fatalf("ClearCart: %v", err)
+	}
+	lines, err = sess.CartContents(ctx)
+	if err != nil {
+		t.Fatalf("CartContents: %v", err)
+	}
+	if len(lines) != 0 {
+		t.Errorf("len(lines) = %d after ClearCart, want 0", len(lines))
+	}
+}
+
+func TestSessionCheckoutRejected(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	srv.Reject = func(path string, form url.Values) (string, string) {
+		return "deliveryAddress", "Looks like there's a problem with that address."
+	}
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	_, err = sess.Checkout(ctx, testCustomer, testAddress, "")
+	if want := (BadRequestError{"address"}); err != want {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
+
+func TestSessionOrderDeclined(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	c := &Client{BaseURL: srv.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	if _, err := sess.Checkout(ctx, testCustomer, testAddress, ""); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	srv.Reject = func(path string, form url.Values) (string, string) {
+		if path == "/order/payment" {
+			return "", "Your card was declined."
+		}
+		return "", ""
+	}
+	_, err = sess.Order(ctx, testPaymentMethod(), Delivery)
+	if want := (ForbiddenError{"card was declined"}); err != want {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+	state, _ := srv.Session(sess.ID)
+	if state.Payment != nil {
+		t.Errorf("payment = %v, want none", state.Payment)
+	}
+}
+
+func TestSessionOrderUnrecognizedError(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	// Chili's error pages haven't all been seen, so serve one that
+	// parseFormError doesn't recognize in response to the payment.
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Path == "/order/payment" {
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, "<html><body><h1>Something went wrong.</h1></body></html>")
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}))
+	defer front.Close()
+	c := &Client{BaseURL: front.URL}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.Cart(ctx, testTripleDipper); err != nil {
+		t.Fatalf("Cart: %v", err)
+	}
+	if _, err := sess.Checkout(ctx, testCustomer, testAddress, ""); err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	_, err = sess.Order(ctx, testPaymentMethod(), Delivery)
+	if err == nil {
+		t.Fatal("Order succeeded")
+	}
+	var bre BadRequestError
+	var fe ForbiddenError
+	if errors.As(err, &bre) || errors.As(err, &fe) || errors.Is(err, ErrSessionExpired) {
+		t.Errorf("err = %v, want a generic error", err)
+	}
+}
+
+// equal returns true if the given string slices are equal.
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
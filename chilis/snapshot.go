@@ -0,0 +1,208 @@
+package chilis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A snapshot is a response from Chili's as it's saved when it can't be parsed.
+type snapshot struct {
+	Time       time.Time   `json:"time"`
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Error      string      `json:"error"`
+	// BodyFile is the name of the file that the body is saved to, which is
+	// in the same directory as the snapshot.
+	BodyFile string `json:"bodyFile"`
+	body     []byte
+}
+
+// recorder is an http.RoundTripper that keeps a copy of the latest response so
+// that it can be saved to dir if it can't be parsed.
+type recorder struct {
+	rt  http.RoundTripper
+	dir string
+
+	mu   sync.Mutex
+	last *snapshot
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.rt.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.last = &snapshot{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		body:       body,
+	}
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// save redacts the latest response and writes it to the recorder's directory
+// along with the error that parsing it failed with.
+func (r *recorder) save(perr error) error {
+	r.mu.Lock()
+	last := r.last
+	r.mu.Unlock()
+	if last == nil {
+		return nil
+	}
+
+	snap := *last
+	snap.Time = time.Now().UTC()
+	snap.Error = perr.Error()
+	snap.Header = redactHeader(snap.Header)
+	body := redactBody(snap.body)
+
+	name := snap.Time.Format("20060102T150405.000000000") + "-" + snapshotSlug(snap.URL)
+	ext := ".html"
+	if strings.Contains(snap.Header.Get("Content-Type"), "json") {
+		ext = ".json"
+	}
+	snap.BodyFile = name + ext
+
+	err := os.MkdirAll(r.dir, 0700)
+	if err != nil {
+		return fmt.Errorf("saving snapshot: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(r.dir, snap.BodyFile), body, 0600)
+	if err != nil {
+		return fmt.Errorf("saving snapshot: %v", err)
+	}
+	meta, err := json.MarshalIndent(snap, "", "\t")
+	if err != nil {
+		return fmt.Errorf("saving snapshot: %v", err)
+	}
+	err = os.WriteFile(filepath.Join(r.dir, name+".response.json"), meta, 0600)
+	if err != nil {
+		return fmt.Errorf("saving snapshot: %v", err)
+	}
+	return nil
+}
+
+// snapshot saves the Session's latest response if its Client has a
+// SnapshotDir and the given error is a failure to parse the response, rather
+// than one of the package's typed errors. Snapshots are saved on a best-effort
+// basis. It returns the given error.
+func (s *Session) snapshot(err error) error {
+	if err == nil || s.rec == nil || Code(err) != CodeUpstream {
+		return err
+	}
+	s.rec.save(err)
+	return err
+}
+
+// snapshotSlug returns a name for a snapshot of a response from the given URL
+// that can be used in file names.
+func snapshotSlug(raw string) string {
+	path := raw
+	if u, err := url.Parse(raw); err == nil {
+		path = u.Path
+	}
+	slug := strings.ReplaceAll(strings.Trim(path, "/"), "/", "_")
+	if slug == "" {
+		slug = "root"
+	}
+	return slug
+}
+
+// The replacements for redacted values.
+const (
+	redactedCSRF   = "REDACTED-CSRF-TOKEN"
+	redactedCard   = "REDACTED-CARD-NUMBER"
+	redactedCookie = "REDACTED"
+)
+
+// csrfPatterns match the CSRF tokens on Chili's pages. Every occurrence of a
+// matched token is redacted, including the ones in scripts.
+var csrfPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`name="_csrf"\s+value="([^"]+)"`),
+	regexp.MustCompile(`value="([^"]+)"\s+name="_csrf"`),
+	regexp.MustCompile(`name="_csrf"\s+content="([^"]+)"`),
+}
+
+// cardPattern matches runs of digits, optionally separated by spaces or
+// hyphens, that are as long as card numbers.
+var cardPattern = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+
+// redactBody returns a copy of the given response body with its CSRF tokens and
+// anything that looks like a card number redacted.
+func redactBody(body []byte) []byte {
+	for _, p := range csrfPatterns {
+		for _, m := range p.FindAllSubmatch(body, -1) {
+			body = bytes.ReplaceAll(body, m[1], []byte(redactedCSRF))
+		}
+	}
+	return cardPattern.ReplaceAllFunc(body, func(b []byte) []byte {
+		if !luhn(b) {
+			return b
+		}
+		return []byte(redactedCard)
+	})
+}
+
+// redactHeader returns a copy of the given response header with the values of
+// the cookies that it sets redacted.
+func redactHeader(h http.Header) http.Header {
+	h = h.Clone()
+	for i, c := range h.Values("Set-Cookie") {
+		name := c
+		if j := strings.IndexByte(c, '='); j >= 0 {
+			name = c[:j]
+		}
+		attrs := ""
+		if j := strings.IndexByte(c, ';'); j >= 0 {
+			attrs = c[j:]
+		}
+		h["Set-Cookie"][i] = name + "=" + redactedCookie + attrs
+	}
+	return h
+}
+
+// luhn reports whether the digits in the given bytes pass the Luhn check that
+// every card number passes.
+func luhn(b []byte) bool {
+	sum := 0
+	double := false
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < '0' || b[i] > '9' {
+			continue
+		}
+		d := int(b[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,88 @@
+package chilis
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+func TestRedactBody(t *testing.T) {
+	page, err := os.ReadFile("testdata/confirmation.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := "ee90ab46-765b-41d6-b14c-e190a102b14c"
+	page = append(page, `<input name="cardNumber" value="4111 1111 1111 1111"/><span>1234567890123456</span>`...)
+
+	got := redactBody(page)
+	if bytes.Contains(got, []byte(token)) {
+		t.Error("CSRF token wasn't redacted")
+	}
+	if bytes.Contains(got, []byte("4111 1111 1111 1111")) {
+		t.Error("card number wasn't redacted")
+	}
+	if !bytes.Contains(got, []byte("1234567890123456")) {
+		t.Error("number that isn't a card number was redacted")
+	}
+	if !bytes.Contains(page, []byte(token)) {
+		t.Error("original body was modified")
+	}
+}
+
+func TestSessionSnapshot(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer("testdata")
+	defer srv.Close()
+	dir := t.TempDir()
+	c := &Client{BaseURL: srv.URL, SnapshotDir: dir}
+
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	td := testDipper{testItem{"Nachos", nil}}
+	cartErr := sess.Cart(ctx, td)
+	var pe *ParseError
+	if !errors.As(cartErr, &pe) {
+		t.Fatalf("err = %v, want ParseError", cartErr)
+	}
+
+	metas, err := filepath.Glob(filepath.Join(dir, "*.response.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(metas) != 1 {
+		t.Fatalf("saved %d snapshots, want 1", len(metas))
+	}
+	raw, err := os.ReadFile(metas[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(raw, &snap); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(snap.URL, "/menu/appetizers/triple-dipper") {
+		t.Errorf("URL = %s, want triple dipper page", snap.URL)
+	}
+	if snap.StatusCode != 200 {
+		t.Errorf("StatusCode = %d, want 200", snap.StatusCode)
+	}
+	if snap.Error != cartErr.Error() {
+		t.Errorf("Error = %s, want %s", snap.Error, cartErr)
+	}
+	body, err := os.ReadFile(filepath.Join(dir, snap.BodyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(body, []byte(redactedCSRF)) {
+		t.Error("snapshot's CSRF token wasn't redacted")
+	}
+}
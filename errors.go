@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/cnnrmnn/godipper/chilis"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// errUnauthenticated is returned when a request that requires a logged in user
+// has none.
+var errUnauthenticated = errors.New("no session found")
+
+// errTotalChanged is returned when an order's total changes between checking
+// out and placing the order.
+var errTotalChanged = errors.New("order total changed, review the order before placing it")
+
+// A notFoundError is returned when a resource doesn't exist or doesn't belong
+// to the current user.
+type notFoundError struct {
+	resource string
+}
+
+func (nfe notFoundError) Error() string {
+	return nfe.resource + " not found"
+}
+
+// A badRequestError is returned when a request can't be fulfilled because of
+// the named field's value or state. It's like chilis.BadRequestError but with
+// an explanation.
+type badRequestError struct {
+	field  string
+	reason string
+}
+
+func (bre badRequestError) Error() string {
+	return bre.reason
+}
+
+// An upstreamError is an error returned by package chilis.
+type upstreamError struct {
+	err error
+}
+
+func (ue upstreamError) Error() string {
+	return ue.err.Error()
+}
+
+func (ue upstreamError) Unwrap() error {
+	return ue.err
+}
+
+// upstream marks the given error, if any, as returned by package chilis.
+func upstream(err error) error {
+	if err == nil {
+		return nil
+	}
+	return upstreamError{err}
+}
+
+// The codes of errors that don't come from package chilis.
+const (
+	codeUnauthenticated = "UNAUTHENTICATED"
+	codeNotFound        = "NOT_FOUND"
+	codeTotalChanged    = "TOTAL_CHANGED"
+	codeInvalidQuery    = "INVALID_QUERY"
+	codeInternal        = "INTERNAL"
+)
+
+// errorCode returns the code of the given error returned by a resolver and,
+// for invalid input, the offending field.
+func errorCode(err error) (code, field string) {
+	var nfe notFoundError
+	var bre badRequestError
+	var cbre chilis.BadRequestError
+	var ue upstreamError
+	switch {
+	case errors.Is(err, errUnauthenticated):
+		return codeUnauthenticated, ""
+	case errors.Is(err, errTotalChanged):
+		return codeTotalChanged, ""
+	case errors.As(err, &nfe):
+		return codeNotFound, ""
+	case errors.As(err, &bre):
+		return string(chilis.CodeBadRequest), bre.field
+	case errors.As(err, &cbre):
+		return string(chilis.CodeBadRequest), cbre.Field
+	case errors.As(err, &ue):
+		return string(chilis.Code(ue.err)), ""
+	}
+	return codeInternal, ""
+}
+
+// formatError formats an error in a GraphQL response, adding its code and, for
+// invalid input, the offending field to its extensions so that clients don't
+// have to match on messages. Errors in the query itself have the code
+// INVALID_QUERY.
+func formatError(err error) gqlerrors.FormattedError {
+	if err == nil {
+		return gqlerrors.NewFormattedError("unknown error")
+	}
+	fe := gqlerrors.FormatError(err)
+	code, field := codeInvalidQuery, ""
+	var ge *gqlerrors.Error
+	if errors.As(err, &ge) && ge.OriginalError != nil {
+		code, field = errorCode(ge.OriginalError)
+	}
+	fe.Extensions = map[string]interface{}{"code": code}
+	if field != "" {
+		fe.Extensions["field"] = field
+	}
+	return fe
+}
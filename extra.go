@@ -78,6 +78,34 @@ func (es extraService) findByItem(iid int) ([]*Extra, error) {
 	return exts, nil
 }
 
+// validate returns a badRequestError if any of the given extras isn't permitted
+// with the item value with the given ID or is chosen more than once. Its field
+// is the extra's index, like extras[1]. The values of valid extras are set.
+func (es extraService) validate(ivid int, exs []*Extra) error {
+	permitted, err := es.values(ivid)
+	if err != nil {
+		return err
+	}
+	values := make(map[int]string)
+	for _, e := range permitted {
+		values[e.ValueID] = e.Value
+	}
+	chosen := make(map[int]bool)
+	for i, e := range exs {
+		field := fmt.Sprintf("extras[%d]", i)
+		v, ok := values[e.ValueID]
+		if !ok {
+			return badRequestError{field, fmt.Sprintf("extra %d isn't permitted with the item", e.ValueID)}
+		}
+		if chosen[e.ValueID] {
+			return badRequestError{field, fmt.Sprintf("%s is chosen more than once", v)}
+		}
+		chosen[e.ValueID] = true
+		e.Value = v
+	}
+	return nil
+}
+
 // create creates the given extra in the given transaction.
 func (es extraService) create(e *Extra, tx *sql.Tx) error {
 	q := "INSERT INTO extras (item_id, extra_value_id) VALUES (?, ?)"
@@ -111,6 +139,20 @@ func (es extraService) destroy(iid int, tx *sql.Tx) error {
 	return nil
 }
 
+// destroyByID destroys the extra with the given ID in the given transaction.
+func (es extraService) destroyByID(id int, tx *sql.Tx) error {
+	q := "DELETE FROM extras WHERE extra_id = ?"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return fmt.Errorf("preparing extra deletion query: %v", err)
+	}
+	_, err = stmt.Exec(id)
+	if err != nil {
+		return fmt.Errorf("executing extra deletion query: %v", err)
+	}
+	return nil
+}
+
 // extraType is the GraphQL type for Extra.
 var extraType = graphql.NewObject(
 	graphql.ObjectConfig{
@@ -1,8 +1,9 @@
 module github.com/cnnrmnn/godipper
 
-go 1.16
+go 1.18
 
 require (
+	github.com/DATA-DOG/go-sqlmock v1.5.2
 	github.com/alexedwards/scs/v2 v2.4.0
 	github.com/antchfx/htmlquery v1.2.3
 	github.com/durango/go-credit-card v0.0.0-20200501133251-afc6bc77117d
@@ -11,6 +12,10 @@ require (
 	github.com/graphql-go/handler v0.2.3
 	github.com/rs/cors v1.7.0
 	golang.org/x/net v0.0.0-20200421231249-e086a090c8fd
-	golang.org/x/term v0.0.0-20201126162022-7de9c90e9dd1 // indirect
+)
+
+require (
+	github.com/antchfx/xpath v1.1.6 // indirect
+	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
 	golang.org/x/text v0.3.3 // indirect
 )
@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/alexedwards/scs/v2"
+	"github.com/cnnrmnn/godipper/chilis"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/graphql-go/handler"
 	"github.com/rs/cors"
 )
 
 func main() {
+	syncMenu := flag.String("sync-menu", "", "sync item and extra values with the menu of the Chili's location with the given restaurant ID and exit")
+	product := flag.String("product", chilis.TripleDipperProduct.ID, "the ID of the product whose menu -sync-menu syncs")
+	menuItem := flag.String("menu-item", "", "the name of a menu item whose modifier values -sync-menu syncs instead of a product's menu")
+	menuPath := flag.String("menu-path", "", "the path of the Chili's page of the menu item that -menu-item names")
+	apply := flag.Bool("apply", false, "apply the changes found by -sync-menu instead of only reporting them")
+	flag.Parse()
+
 	db, err := sql.Open("mysql", os.Getenv("DSN"))
 	if err != nil {
 		log.Fatalf("opening databse: %v", err)
@@ -20,19 +31,72 @@ func main() {
 	defer db.Close()
 
 	sm := scs.New()
+	// CHILIS_URL is only set when pointing the application at something other
+	// than the Chili's website (e.g. a proxy or a local stand-in server).
+	// CHILIS_SNAPSHOT_DIR is only set when Chili's responses that can't be
+	// parsed should be saved for debugging.
+	cc := &chilis.Client{
+		BaseURL:     os.Getenv("CHILIS_URL"),
+		Timeout:     30 * time.Second,
+		MinGap:      250 * time.Millisecond,
+		Retries:     2,
+		SnapshotDir: os.Getenv("CHILIS_SNAPSHOT_DIR"),
+		OnAttempt: func(a chilis.Attempt) {
+			if a.Err != nil || a.StatusCode >= 500 {
+				log.Printf("chilis: %s %s attempt %d failed after %v (status %d): %v",
+					a.Method, a.URL, a.N, a.Duration, a.StatusCode, a.Err)
+			}
+		},
+	}
+	chilis.OnMatch = func(m chilis.Match) {
+		if m.Fallback() {
+			log.Printf("chilis: %s %s found by selector version %d of %d",
+				m.Page, m.Field, m.Version, m.Latest)
+		}
+	}
+
+	if *syncMenu != "" && *menuItem != "" {
+		if *menuPath == "" {
+			log.Fatalf("syncing menu item: -menu-path is required")
+		}
+		ms := menuService{db: db, cc: cc}
+		d, err := ms.syncMenuItem(context.Background(), *syncMenu, *menuItem, *menuPath, *apply)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s", d)
+		return
+	}
+	if *syncMenu != "" {
+		p, ok := chilis.FindProduct(*product)
+		if !ok {
+			log.Fatalf("syncing menu: unknown product %s", *product)
+		}
+		ms := menuService{db: db, cc: cc}
+		d, err := ms.sync(context.Background(), *syncMenu, p, *apply)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("%s", d)
+		return
+	}
 
 	us := userService{db: db, sm: sm}
 	as := addressService{db: db, us: us}
+	ls := locationService{cc: cc, as: as, us: us}
 	es := extraService{db: db}
 	is := itemService{db: db, es: es}
 	tds := tripleDipperService{db: db, is: is}
-	ors := orderService{db: db, as: as, tds: tds, us: us}
+	ois := orderItemService{db: db}
+	ors := orderService{db: db, cc: cc, as: as, tds: tds, ois: ois, us: us}
 	svc := &service{
 		user:         us,
 		address:      as,
+		location:     ls,
 		extra:        es,
 		item:         is,
 		tripleDipper: tds,
+		orderItem:    ois,
 		order:        ors,
 	}
 
@@ -43,9 +107,10 @@ func main() {
 		log.Fatalf("starting server: %v", err)
 	}
 	mux.Handle("/graphql", handler.New(&handler.Config{
-		Schema:   &schema,
-		Pretty:   true,
-		GraphiQL: true,
+		Schema:        &schema,
+		Pretty:        true,
+		GraphiQL:      true,
+		FormatErrorFn: formatError,
 	}))
 
 	assetServer := http.FileServer(http.Dir("./assets"))
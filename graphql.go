@@ -9,25 +9,33 @@ import (
 // elsewhere.
 func schema(svc *service) (graphql.Schema, error) {
 	queryFields := graphql.Fields{
-		"me":           me(svc),
-		"itemValues":   itemValues(svc),
-		"addresses":    addresses(svc),
-		"orders":       orders(svc),
-		"currentOrder": currentOrder(svc),
+		"me":              me(svc),
+		"itemValues":      itemValues(svc),
+		"menuItems":       menuItems(svc),
+		"addresses":       addresses(svc),
+		"nearbyLocations": nearbyLocations(svc),
+		"orders":          orders(svc),
+		"currentOrder":    currentOrder(svc),
+		"deliverySlots":   deliverySlots(svc),
+		"deliveryQuote":   deliveryQuote(svc),
 	}
 	queryType := graphql.NewObject(
 		graphql.ObjectConfig{Name: "Query", Fields: queryFields},
 	)
 	mutationFields := graphql.Fields{
-		"sendCode":       sendCode(svc),
-		"signUp":         signUp(svc),
-		"logIn":          logIn(svc),
-		"logOut":         logOut(svc),
-		"createAddress":  createAddress(svc),
-		"addToCart":      addToCart(svc),
-		"removeFromCart": removeFromCart(svc),
-		"checkOut":       checkOut(svc),
-		"placeOrder":     placeOrder(svc),
+		"sendCode":               sendCode(svc),
+		"signUp":                 signUp(svc),
+		"logIn":                  logIn(svc),
+		"logOut":                 logOut(svc),
+		"createAddress":          createAddress(svc),
+		"addToCart":              addToCart(svc),
+		"updateCartItem":         updateCartItem(svc),
+		"removeFromCart":         removeFromCart(svc),
+		"addMenuItemToCart":      addMenuItemToCart(svc),
+		"removeMenuItemFromCart": removeMenuItemFromCart(svc),
+		"checkOut":               checkOut(svc),
+		"checkOutPickup":         checkOutPickup(svc),
+		"placeOrder":             placeOrder(svc),
 	}
 	mutationType := graphql.NewObject(
 		graphql.ObjectConfig{Name: "Mutation", Fields: mutationFields},
@@ -2,8 +2,10 @@ package main
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
+	"github.com/cnnrmnn/godipper/chilis"
 	"github.com/graphql-go/graphql"
 )
 
@@ -38,10 +40,16 @@ type itemService struct {
 	es extra
 }
 
-// values returns a slice of all available item values.
-func (is itemService) values() ([]*Item, error) {
-	q := "SELECT item_value_id, item_value, description, image_path FROM item_values"
-	rows, err := is.db.Query(q)
+// values returns a slice of all available item values of the product with the
+// given ID. Item values that haven't been described since being synced from the
+// menu aren't available.
+func (is itemService) values(pid string) ([]*Item, error) {
+	q := `
+		SELECT item_value_id, item_value, description, image_path
+		FROM item_values
+		WHERE product_id = ?
+			AND description IS NOT NULL AND image_path IS NOT NULL`
+	rows, err := is.db.Query(q, pid)
 	if err != nil {
 		return nil, fmt.Errorf("finding item values: %v", err)
 	}
@@ -68,13 +76,15 @@ func (is itemService) values() ([]*Item, error) {
 }
 
 // findByTripleDipper returns a slice of items that belong to the triple dipper
-// with the given ID.
+// with the given ID in the order of their selection groups. Items are created
+// in that order and updated in place, so it's the order of their IDs.
 func (is itemService) findByTripleDipper(tdid int) ([]*Item, error) {
 	q := `
 		SELECT i.item_id, i.triple_dipper_id, i.item_value_id, iv.item_value
 		FROM items i INNER JOIN item_values iv
 		ON i.item_value_id = iv.item_value_id
-		WHERE i.triple_dipper_id = ?`
+		WHERE i.triple_dipper_id = ?
+		ORDER BY i.item_id`
 	rows, err := is.db.Query(q, tdid)
 	if err != nil {
 		return nil, fmt.Errorf("finding item by triple dipper ID: %v", err)
@@ -101,6 +111,44 @@ func (is itemService) findByTripleDipper(tdid int) ([]*Item, error) {
 	return its, nil
 }
 
+// validate returns a badRequestError if the given items can't make up the given
+// product: it must have exactly as many items as it has selections, each item
+// must be one of the product's available item values that's offered by the
+// selection group at the item's index, and each item's extras must be
+// permitted with it. Its field is the items if there are too few or too
+// many, or else the offending item or extra, like items[1] or
+// items[1].extras[0]. The values of valid items and extras are set.
+func (is itemService) validate(p chilis.Product, its []*Item) error {
+	if n := p.Selections(); len(its) != n {
+		return badRequestError{"items", fmt.Sprintf("%s must have exactly %d items", p.Name, n)}
+	}
+	q := `
+		SELECT iv.item_value
+		FROM item_values iv INNER JOIN item_value_groups g
+		ON iv.item_value_id = g.item_value_id
+		WHERE iv.item_value_id = ? AND iv.product_id = ? AND g.group_index = ?
+			AND iv.description IS NOT NULL AND iv.image_path IS NOT NULL`
+	for i, it := range its {
+		field := fmt.Sprintf("items[%d]", i)
+		err := is.db.QueryRow(q, it.ValueID, p.ID, i).Scan(&it.Value)
+		if errors.Is(err, sql.ErrNoRows) {
+			return badRequestError{field, fmt.Sprintf("item %d isn't offered for %s of %s", it.ValueID, p.Groups[i], p.Name)}
+		}
+		if err != nil {
+			return fmt.Errorf("finding item value: %v", err)
+		}
+		err = is.es.validate(it.ValueID, it.Extras)
+		var bre badRequestError
+		if errors.As(err, &bre) {
+			return badRequestError{field + "." + bre.field, it.Value + ": " + bre.reason}
+		}
+		if err != nil {
+			return fmt.Errorf("validating %s extras: %v", it.Value, err)
+		}
+	}
+	return nil
+}
+
 // create creates the given item in the given transaction.
 func (is itemService) create(it *Item, tx *sql.Tx) error {
 	q := "INSERT INTO items (triple_dipper_id, item_value_id) VALUES(?, ?)"
@@ -126,6 +174,103 @@ func (is itemService) create(it *Item, tx *sql.Tx) error {
 	return nil
 }
 
+// update replaces the given old items of the triple dipper with the given ID
+// with the given items in the given transaction. Items are updated in place
+// by index, so each keeps the ID of the old item in its selection group and
+// the triple dipper's items keep their order. Old items without a
+// counterpart are destroyed and items without one are created.
+func (is itemService) update(tdid int, old, its []*Item, tx *sql.Tx) error {
+	for i, it := range its {
+		it.TripleDipperID = tdid
+		if i >= len(old) {
+			if err := is.create(it, tx); err != nil {
+				return fmt.Errorf("inserting item: %v", err)
+			}
+			continue
+		}
+		it.ID = old[i].ID
+		if it.ValueID != old[i].ValueID {
+			if err := is.updateValue(it, tx); err != nil {
+				return err
+			}
+		}
+		if err := is.updateExtras(old[i].Extras, it, tx); err != nil {
+			return fmt.Errorf("updating item extras: %v", err)
+		}
+	}
+	for i := len(its); i < len(old); i++ {
+		if err := is.destroyByID(old[i].ID, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateValue sets the value of the given item in the given transaction.
+func (is itemService) updateValue(it *Item, tx *sql.Tx) error {
+	q := "UPDATE items SET item_value_id = ? WHERE item_id = ?"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return fmt.Errorf("preparing item update query: %v", err)
+	}
+	_, err = stmt.Exec(it.ValueID, it.ID)
+	if err != nil {
+		return fmt.Errorf("executing item update query: %v", err)
+	}
+	return nil
+}
+
+// updateExtras replaces the given old extras of the given item with its extras
+// in the given transaction. Each extra is matched with an old extra with the
+// same value, which is kept. Old extras that aren't matched are destroyed and
+// extras that aren't matched are created.
+func (is itemService) updateExtras(old []*Extra, it *Item, tx *sql.Tx) error {
+	unmatched := append([]*Extra(nil), old...)
+	for _, e := range it.Extras {
+		e.ItemID = it.ID
+		match := -1
+		for i, o := range unmatched {
+			if o.ValueID == e.ValueID {
+				match = i
+				break
+			}
+		}
+		if match == -1 {
+			if err := is.es.create(e, tx); err != nil {
+				return err
+			}
+			continue
+		}
+		e.ID = unmatched[match].ID
+		unmatched = append(unmatched[:match], unmatched[match+1:]...)
+	}
+	for _, o := range unmatched {
+		if err := is.es.destroyByID(o.ID, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// destroyByID destroys the item with the given ID and its extras in the given
+// transaction.
+func (is itemService) destroyByID(id int, tx *sql.Tx) error {
+	err := is.es.destroy(id, tx)
+	if err != nil {
+		return fmt.Errorf("destroying item extras: %v", err)
+	}
+	q := "DELETE FROM items WHERE item_id = ?"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		return fmt.Errorf("preparing item deletion query: %v", err)
+	}
+	_, err = stmt.Exec(id)
+	if err != nil {
+		return fmt.Errorf("executing item deletion query: %v", err)
+	}
+	return nil
+}
+
 // destroy destroys the items with the given triple dipper ID.
 func (is itemService) destroy(tdid int, tx *sql.Tx) error {
 	q := "SELECT item_id FROM items WHERE triple_dipper_id = ?"
@@ -220,13 +365,37 @@ var itemInputType = graphql.NewInputObject(
 	},
 )
 
+// itemsArg returns the items in the given GraphQL argument, which is a list of
+// itemInputType.
+func itemsArg(arg interface{}) []*Item {
+	var items []*Item
+	for _, item := range arg.([]interface{}) {
+		iin := item.(map[string]interface{})
+		ivid := iin["valueId"].(int)
+		var extras []*Extra
+		for _, ein := range iin["extras"].([]interface{}) {
+			evid := ein.(int)
+			extras = append(extras, &Extra{ValueID: evid})
+		}
+		items = append(items, &Item{ValueID: ivid, Extras: extras})
+	}
+	return items
+}
+
 // itemValues returns a GraphQL query field that resolves to a list of
-// available item values.
+// available item values of the given product, which is the triple dipper by
+// default.
 func itemValues(svc *service) *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemValueType))),
+		Args: graphql.FieldConfigArgument{
+			"productId": &graphql.ArgumentConfig{
+				Type:         graphql.String,
+				DefaultValue: chilis.TripleDipperProduct.ID,
+			},
+		},
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			return svc.item.values()
+			return svc.item.values(p.Args["productId"].(string))
 		},
 	}
 }
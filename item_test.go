@@ -0,0 +1,256 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cnnrmnn/godipper/chilis"
+)
+
+// newTestItemService returns an itemService with a real extraService whose
+// database is mocked.
+func newTestItemService(t *testing.T) (itemService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return itemService{db: db, es: extraService{db: db}}, mock
+}
+
+// expectItemValue expects the item value with the given ID to be found in the
+// selection group with the given index with the given value, or not found if
+// the value is empty.
+func expectItemValue(mock sqlmock.Sqlmock, id, group int, value string) {
+	rows := sqlmock.NewRows([]string{"item_value"})
+	if value != "" {
+		rows.AddRow(value)
+	}
+	mock.ExpectQuery("SELECT iv.item_value\\s+FROM item_values iv INNER JOIN item_value_groups").
+		WithArgs(id, chilis.TripleDipperProduct.ID, group).
+		WillReturnRows(rows)
+}
+
+// expectExtraValues expects the extra values permitted with the item value
+// with the given ID to be found. Ranch Dressing is permitted with item value
+// 1, and Bleu Cheese Dressing with every item value.
+func expectExtraValues(mock sqlmock.Sqlmock, ivid int) {
+	rows := sqlmock.NewRows([]string{"extra_value_id", "extra_value"}).
+		AddRow(2, "Bleu Cheese Dressing")
+	if ivid == 1 {
+		rows.AddRow(6, "Ranch Dressing")
+	}
+	mock.ExpectQuery("FROM extra_values").
+		WithArgs(ivid).
+		WillReturnRows(rows)
+}
+
+// testItems returns items with the given value IDs. The first item has the
+// extras with the given value IDs.
+func testItems(ivids []int, evids ...int) []*Item {
+	var its []*Item
+	for _, id := range ivids {
+		its = append(its, &Item{ValueID: id})
+	}
+	for _, id := range evids {
+		its[0].Extras = append(its[0].Extras, &Extra{ValueID: id})
+	}
+	return its
+}
+
+func TestItemServiceValidate(t *testing.T) {
+	is, mock := newTestItemService(t)
+	expectItemValue(mock, 1, 0, "Awesome Blossom Petals")
+	expectExtraValues(mock, 1)
+	expectItemValue(mock, 2, 1, "Big Mouth® Bites")
+	expectExtraValues(mock, 2)
+	expectItemValue(mock, 3, 2, "Boneless Buffalo Wings")
+	expectExtraValues(mock, 3)
+
+	its := testItems([]int{1, 2, 3}, 6, 2)
+	if err := is.validate(chilis.TripleDipperProduct, its); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if its[1].Value != "Big Mouth® Bites" {
+		t.Errorf("item value = %s, want Big Mouth® Bites", its[1].Value)
+	}
+	if its[0].Extras[0].Value != "Ranch Dressing" {
+		t.Errorf("extra value = %s, want Ranch Dressing", its[0].Extras[0].Value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestItemServiceValidateInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		its    []*Item
+		expect func(sqlmock.Sqlmock)
+		field  string
+	}{
+		{
+			"too few items", testItems([]int{1, 2}),
+			func(sqlmock.Sqlmock) {},
+			"items",
+		},
+		{
+			"unknown item", testItems([]int{1, 9, 3}),
+			func(mock sqlmock.Sqlmock) {
+				expectItemValue(mock, 1, 0, "Awesome Blossom Petals")
+				expectExtraValues(mock, 1)
+				expectItemValue(mock, 9, 1, "")
+			},
+			"items[1]",
+		},
+		{
+			"too many items", testItems([]int{1, 2, 3, 4}),
+			func(sqlmock.Sqlmock) {},
+			"items",
+		},
+		{
+			"item not offered by its group", testItems([]int{1, 2, 3}),
+			func(mock sqlmock.Sqlmock) {
+				expectItemValue(mock, 1, 0, "Awesome Blossom Petals")
+				expectExtraValues(mock, 1)
+				expectItemValue(mock, 2, 1, "Big Mouth® Bites")
+				expectExtraValues(mock, 2)
+				expectItemValue(mock, 3, 2, "")
+			},
+			"items[2]",
+		},
+		{
+			"extra not permitted", testItems([]int{2, 1, 3}, 2, 6),
+			func(mock sqlmock.Sqlmock) {
+				expectItemValue(mock, 2, 0, "Big Mouth® Bites")
+				expectExtraValues(mock, 2)
+			},
+			"items[0].extras[1]",
+		},
+		{
+			"duplicate extra", testItems([]int{1, 2, 3}, 6, 6),
+			func(mock sqlmock.Sqlmock) {
+				expectItemValue(mock, 1, 0, "Awesome Blossom Petals")
+				expectExtraValues(mock, 1)
+			},
+			"items[0].extras[1]",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			is, mock := newTestItemService(t)
+			test.expect(mock)
+			err := is.validate(chilis.TripleDipperProduct, test.its)
+			var bre badRequestError
+			if !errors.As(err, &bre) || bre.field != test.field {
+				t.Errorf("err = %v, want invalid %s", err, test.field)
+			}
+			if code, field := errorCode(err); code != string(chilis.CodeBadRequest) || field != test.field {
+				t.Errorf("error code, field = %s, %s, want %s, %s", code, field, chilis.CodeBadRequest, test.field)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestItemServiceUpdate(t *testing.T) {
+	is, mock := newTestItemService(t)
+	mock.ExpectBegin()
+	// Item 10 keeps extra 101, gains extra value 7, and loses extra 100.
+	mock.ExpectPrepare("INSERT INTO extras").
+		ExpectExec().
+		WithArgs(10, 7).
+		WillReturnResult(sqlmock.NewResult(102, 1))
+	mock.ExpectPrepare("DELETE FROM extras WHERE extra_id").
+		ExpectExec().
+		WithArgs(100).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Item 12 changes value in place.
+	mock.ExpectPrepare("UPDATE items SET item_value_id").
+		ExpectExec().
+		WithArgs(4, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	old := []*Item{
+		{ID: 10, ValueID: 1, Extras: []*Extra{{ID: 100, ValueID: 6}, {ID: 101, ValueID: 2}}},
+		{ID: 11, ValueID: 2},
+		{ID: 12, ValueID: 3},
+	}
+	its := []*Item{
+		{ValueID: 1, Extras: []*Extra{{ValueID: 2}, {ValueID: 7}}},
+		{ValueID: 2},
+		{ValueID: 4},
+	}
+	tx, err := is.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := is.update(5, old, its, tx); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if its[0].ID != 10 || its[1].ID != 11 || its[2].ID != 12 {
+		t.Errorf("item IDs = %d, %d, %d, want 10, 11, 12", its[0].ID, its[1].ID, its[2].ID)
+	}
+	if its[0].Extras[0].ID != 101 || its[0].Extras[1].ID != 102 {
+		t.Errorf("extra IDs = %d, %d, want 101, 102", its[0].Extras[0].ID, its[0].Extras[1].ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestItemServiceUpdateReorder(t *testing.T) {
+	is, mock := newTestItemService(t)
+	mock.ExpectBegin()
+	// Every item is in a different selection group than before, so each old
+	// item takes the value of the new item at its index and keeps its ID.
+	// Item 10's extra is dropped along with its old value.
+	mock.ExpectPrepare("UPDATE items SET item_value_id").
+		ExpectExec().
+		WithArgs(3, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM extras WHERE extra_id").
+		ExpectExec().
+		WithArgs(100).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE items SET item_value_id").
+		ExpectExec().
+		WithArgs(1, 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("UPDATE items SET item_value_id").
+		ExpectExec().
+		WithArgs(2, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	old := []*Item{
+		{ID: 10, ValueID: 1, Extras: []*Extra{{ID: 100, ValueID: 6}}},
+		{ID: 11, ValueID: 2},
+		{ID: 12, ValueID: 3},
+	}
+	its := testItems([]int{3, 1, 2})
+	tx, err := is.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := is.update(5, old, its, tx); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if its[0].ID != 10 || its[1].ID != 11 || its[2].ID != 12 {
+		t.Errorf("item IDs = %d, %d, %d, want 10, 11, 12", its[0].ID, its[1].ID, its[2].ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+
+	"github.com/cnnrmnn/godipper/chilis"
+	"github.com/graphql-go/graphql"
+)
+
+// locationService implements the location interface. Its methods find Chili's
+// locations.
+type locationService struct {
+	cc *chilis.Client
+	as address
+	us user
+}
+
+// nearby returns the locations in proximity of the current user's address
+// with the given ID, nearest first.
+func (ls locationService) nearby(ctx context.Context, aid int) ([]chilis.NearbyLocation, error) {
+	uid, err := ls.us.idFromSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ls.as.findByID(aid)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != uid {
+		return nil, notFoundError{"address"}
+	}
+	sess, err := ls.cc.StartSession(ctx)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	locs, err := sess.Locations(ctx, a.Address)
+	return locs, upstream(err)
+}
+
+// setNearbyLocation sets the session's location to the location with the given
+// ID provided that it's in proximity of the given address and available.
+func setNearbyLocation(ctx context.Context, sess *chilis.Session, addr chilis.Address, id string) error {
+	locs, err := sess.Locations(ctx, addr)
+	if err != nil {
+		return upstream(err)
+	}
+	for _, l := range locs {
+		if l.ID != id {
+			continue
+		}
+		if err := l.Available(); err != nil {
+			return upstream(err)
+		}
+		return upstream(sess.SetLocationByID(ctx, id))
+	}
+	return badRequestError{"location", "location is not in proximity of address"}
+}
+
+// locationType is the GraphQL type for chilis.Location.
+var locationType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Location",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"phone": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"street": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					l := p.Source.(*chilis.Location)
+					return l.Address.Street, nil
+				},
+			},
+			"city": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					l := p.Source.(*chilis.Location)
+					return l.Address.City, nil
+				},
+			},
+			"state": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					l := p.Source.(*chilis.Location)
+					return l.Address.State, nil
+				},
+			},
+			"zip": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					l := p.Source.(*chilis.Location)
+					return l.Address.Zip, nil
+				},
+			},
+		},
+	},
+)
+
+// nearbyLocationType is the GraphQL type for chilis.NearbyLocation.
+var nearbyLocationType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "NearbyLocation",
+		Fields: graphql.Fields{
+			"location": &graphql.Field{
+				Type: graphql.NewNonNull(locationType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					nl := p.Source.(chilis.NearbyLocation)
+					return &nl.Location, nil
+				},
+			},
+			"distance": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Float),
+			},
+			"acceptsOrders": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"delivers": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+		},
+	},
+)
+
+// nearbyLocations returns a GraphQL query field that resolves to the list of
+// locations in proximity of the given address, nearest first.
+func nearbyLocations(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(nearbyLocationType))),
+		Args: graphql.FieldConfigArgument{
+			"addressId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return svc.location.nearby(p.Context, p.Args["addressId"].(int))
+		},
+	}
+}
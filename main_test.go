@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cnnrmnn/godipper/chilis"
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+// fixtures is the directory that chilistest serves Chili's pages from.
+const fixtures = "chilis/testdata"
+
+var testUser = &User{
+	ID: 1,
+	Customer: chilis.Customer{
+		FirstName: "Jane",
+		LastName:  "Doe",
+		Phone:     "9195550123",
+		Email:     "jane@example.com",
+	},
+}
+
+var testAddress = &Address{
+	ID:     1,
+	UserID: 1,
+	Address: chilis.Address{
+		Street: "4600 Chapel Hill Blvd.",
+		City:   "Durham",
+		State:  "NC",
+		Zip:    "27707",
+	},
+}
+
+// newTestTripleDipper returns a new triple dipper that belongs to the order
+// with the given ID and whose items are on the dipper fixture's menu.
+func newTestTripleDipper(oid int) *TripleDipper {
+	return &TripleDipper{
+		ID:      1,
+		OrderID: oid,
+		Items: []*Item{
+			{Value: "Awesome Blossom Petals", Extras: []*Extra{{Value: "Ranch Dressing"}}},
+			{Value: "Big Mouth® Bites"},
+			{Value: "Boneless Buffalo Wings", Extras: []*Extra{{Value: "Bleu Cheese Dressing"}}},
+		},
+	}
+}
+
+// testPaymentMethod returns a new PaymentMethod with a valid test card.
+func testPaymentMethod() *chilis.PaymentMethod {
+	return &chilis.PaymentMethod{
+		Number: "4111111111111111",
+		CVV:    "123",
+		Month:  "12",
+		Year:   "2099",
+		Name:   "Jane Doe",
+		Zip:    "27707",
+	}
+}
+
+// fakeUsers is a user service whose current user is always u.
+type fakeUsers struct {
+	user
+	u *User
+}
+
+func (us fakeUsers) me(ctx context.Context) (*User, error) {
+	return us.u, nil
+}
+
+func (us fakeUsers) idFromSession(ctx context.Context) (int, error) {
+	return us.u.ID, nil
+}
+
+// fakeAddresses is an address service that only knows about a.
+type fakeAddresses struct {
+	address
+	a *Address
+}
+
+func (as fakeAddresses) findByID(id int) (*Address, error) {
+	if id != as.a.ID {
+		return nil, errors.New("address not found")
+	}
+	return as.a, nil
+}
+
+// fakeTripleDippers is a triple dipper service whose orders all contain the
+// triple dippers in tdrs.
+type fakeTripleDippers struct {
+	tripleDipper
+	tdrs *[]*TripleDipper
+}
+
+func (tds fakeTripleDippers) findByOrder(oid int) ([]*TripleDipper, error) {
+	return *tds.tdrs, nil
+}
+
+func (tds fakeTripleDippers) update(td *TripleDipper, oid int) error {
+	return nil
+}
+
+// fakeOrderItems is an order item service whose orders all contain the order
+// items in items.
+type fakeOrderItems struct {
+	orderItem
+	items *[]*OrderItem
+}
+
+func (ois fakeOrderItems) findByOrder(oid int) ([]*OrderItem, error) {
+	return *ois.items, nil
+}
+
+// newTestOrderService returns an orderService for testUser that talks to the
+// given fake Chili's server. Its orders table is mocked, and every order
+// contains the triple dippers and items that tdrs and items point to.
+func newTestOrderService(t *testing.T, srv *chilistest.Server, tdrs *[]*TripleDipper, items *[]*OrderItem) (orderService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	ors := orderService{
+		db:  db,
+		cc:  &chilis.Client{BaseURL: srv.URL},
+		as:  fakeAddresses{a: testAddress},
+		tds: fakeTripleDippers{tdrs: tdrs},
+		ois: fakeOrderItems{items: items},
+		us:  fakeUsers{u: testUser},
+	}
+	return ors, mock
+}
+
+// expectCurrent expects the order to be found as its user's current order.
+func expectCurrent(mock sqlmock.Sqlmock, o *Order) {
+	var loc chilis.Location
+	if o.Location != nil {
+		loc = *o.Location
+	}
+	rows := sqlmock.NewRows([]string{
+		"order_id", "user_id", "completed", "location_id", "location_name",
+		"location_phone", "location_street", "location_city", "location_state",
+		"location_zip", "address_id", "session_id", "session_state", "subtotal",
+		"tax", "delivery_fee", "service_fee", "delivery_time", "deliver_at",
+		"order_mode",
+	}).AddRow(o.ID, o.UserID, o.Completed, loc.ID, loc.Name, loc.Phone,
+		loc.Address.Street, loc.Address.City, loc.Address.State,
+		loc.Address.Zip, o.Address.ID, o.SessionID, o.SessionState,
+		o.Subtotal, o.Tax, o.DeliveryFee, o.ServiceFee, o.DeliveryTime,
+		o.DeliverAt, string(o.OrderMode))
+	mock.ExpectQuery(`FROM orders\s+WHERE completed = FALSE AND user_id = \?`).
+		WithArgs(o.UserID).
+		WillReturnRows(rows)
+}
+
+// expectUpdate expects an order to be updated.
+func expectUpdate(mock sqlmock.Sqlmock) {
+	mock.ExpectPrepare("UPDATE orders").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
@@ -0,0 +1,538 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cnnrmnn/godipper/chilis"
+)
+
+// menuService syncs a product's item values, extra values, and the combinations
+// of them that are permitted, and menu items' modifier values, with the Chili's
+// menu.
+type menuService struct {
+	db *sql.DB
+	cc *chilis.Client
+}
+
+// A combination is an extra value that's permitted with an item value. Its ID
+// is zero if it isn't stored.
+type combination struct {
+	id    int
+	item  string
+	extra string
+}
+
+// String returns a string representation of the combination.
+func (c combination) String() string {
+	return c.item + " with " + c.extra
+}
+
+// An itemGroup is a selection group that offers an item value, identified by
+// its index in the product's groups.
+type itemGroup struct {
+	item  string
+	group int
+}
+
+// String returns a string representation of the item group.
+func (g itemGroup) String() string {
+	return fmt.Sprintf("%s in group %d", g.item, g.group+1)
+}
+
+// storedMenu is a product's item values, combinations, and item groups in the
+// database and every extra value, since extra values are shared by products.
+type storedMenu struct {
+	items        map[string]int
+	extras       map[string]int
+	combinations []combination
+	groups       []itemGroup
+}
+
+// A menuDiff is the difference between the Chili's menu and the stored menu.
+// Values that are no longer on the menu are only reported, since existing
+// orders refer to them.
+type menuDiff struct {
+	newItems            []string
+	missingItems        []string
+	newExtras           []string
+	missingExtras       []string
+	newCombinations     []combination
+	removedCombinations []combination
+	newGroups           []itemGroup
+	removedGroups       []itemGroup
+}
+
+// empty reports whether the stored menu is the same as the Chili's menu.
+func (d menuDiff) empty() bool {
+	return len(d.newItems) == 0 && len(d.missingItems) == 0 &&
+		len(d.newExtras) == 0 && len(d.missingExtras) == 0 &&
+		len(d.newCombinations) == 0 && len(d.removedCombinations) == 0 &&
+		len(d.newGroups) == 0 && len(d.removedGroups) == 0
+}
+
+// String returns a report of the diff with one change per line.
+func (d menuDiff) String() string {
+	if d.empty() {
+		return "menu is up to date"
+	}
+	var b strings.Builder
+	for _, it := range d.newItems {
+		fmt.Fprintf(&b, "+ item value %s (Chili's doesn't describe items, so it isn't offered until its description and image_path are set)\n", it)
+	}
+	for _, it := range d.missingItems {
+		fmt.Fprintf(&b, "! item value %s isn't on the menu\n", it)
+	}
+	for _, e := range d.newExtras {
+		fmt.Fprintf(&b, "+ extra value %s\n", e)
+	}
+	for _, e := range d.missingExtras {
+		fmt.Fprintf(&b, "! extra value %s isn't on the menu\n", e)
+	}
+	for _, c := range d.newCombinations {
+		fmt.Fprintf(&b, "+ combination %s\n", c)
+	}
+	for _, c := range d.removedCombinations {
+		fmt.Fprintf(&b, "- combination %s\n", c)
+	}
+	for _, g := range d.newGroups {
+		fmt.Fprintf(&b, "+ item group %s\n", g)
+	}
+	for _, g := range d.removedGroups {
+		fmt.Fprintf(&b, "- item group %s\n", g)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// sync diffs the given product's stored menu against its menu at the Chili's
+// location with the given restaurant ID and returns the diff. If apply is true,
+// new values, combinations, and item groups are inserted and combinations and
+// item groups that are no longer on the menu are deleted.
+func (ms menuService) sync(ctx context.Context, lid string, p chilis.Product, apply bool) (menuDiff, error) {
+	var d menuDiff
+	sess, err := ms.cc.StartSession(ctx)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu: %v", err)
+	}
+	err = sess.SetLocationByID(ctx, lid)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu: %v", err)
+	}
+	m, err := sess.Menu(ctx, p)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu: %v", err)
+	}
+	sm, err := ms.stored(p.ID)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu: %v", err)
+	}
+	d = diffMenu(m, sm)
+	if !apply || d.empty() {
+		return d, nil
+	}
+	err = ms.apply(d, sm, p.ID)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu: %v", err)
+	}
+	return d, nil
+}
+
+// stored returns the stored menu of the product with the given ID.
+func (ms menuService) stored(pid string) (storedMenu, error) {
+	sm := storedMenu{items: make(map[string]int), extras: make(map[string]int)}
+	q := "SELECT item_value_id, item_value FROM item_values WHERE product_id = ?"
+	err := ms.scanValues(sm.items, q, pid)
+	if err != nil {
+		return sm, fmt.Errorf("finding item values: %v", err)
+	}
+	err = ms.scanValues(sm.extras, "SELECT extra_value_id, extra_value FROM extra_values")
+	if err != nil {
+		return sm, fmt.Errorf("finding extra values: %v", err)
+	}
+
+	q = `
+		SELECT cmb.combination_id, iv.item_value, ev.extra_value
+		FROM item_extra_combinations cmb
+			INNER JOIN item_values iv
+			ON cmb.item_value_id = iv.item_value_id
+			INNER JOIN extra_values ev
+			ON cmb.extra_value_id = ev.extra_value_id
+		WHERE iv.product_id = ?
+		ORDER BY cmb.combination_id`
+	rows, err := ms.db.Query(q, pid)
+	if err != nil {
+		return sm, fmt.Errorf("finding item extra combinations: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var c combination
+		err = rows.Scan(&c.id, &c.item, &c.extra)
+		if err != nil {
+			return sm, fmt.Errorf("scanning item extra combination: %v", err)
+		}
+		sm.combinations = append(sm.combinations, c)
+	}
+	err = rows.Err()
+	if err != nil {
+		return sm, fmt.Errorf("reading item extra combinations: %v", err)
+	}
+
+	q = `
+		SELECT iv.item_value, g.group_index
+		FROM item_value_groups g INNER JOIN item_values iv
+		ON g.item_value_id = iv.item_value_id
+		WHERE iv.product_id = ?`
+	rows, err = ms.db.Query(q, pid)
+	if err != nil {
+		return sm, fmt.Errorf("finding item groups: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var g itemGroup
+		err = rows.Scan(&g.item, &g.group)
+		if err != nil {
+			return sm, fmt.Errorf("scanning item group: %v", err)
+		}
+		sm.groups = append(sm.groups, g)
+	}
+	err = rows.Err()
+	if err != nil {
+		return sm, fmt.Errorf("reading item groups: %v", err)
+	}
+	return sm, nil
+}
+
+// scanValues runs the given query, which selects IDs and values, with the
+// given arguments and maps each value to its ID in the given map.
+func (ms menuService) scanValues(vals map[string]int, q string, args ...interface{}) error {
+	rows, err := ms.db.Query(q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var val string
+		err = rows.Scan(&id, &val)
+		if err != nil {
+			return err
+		}
+		vals[val] = id
+	}
+	return rows.Err()
+}
+
+// diffMenu returns the difference between the given Chili's menu and stored
+// menu. Duplicate stored combinations are removed. Extra values are only
+// missing if they're combined with the product's item values.
+func diffMenu(m chilis.Menu, sm storedMenu) menuDiff {
+	var d menuDiff
+	onMenu := make(map[string]bool)
+	extras := make(map[string]bool)
+	permitted := make(map[combination]bool)
+	offered := make(map[itemGroup]bool)
+	for _, it := range m.Items {
+		onMenu[it.Name] = true
+		if _, ok := sm.items[it.Name]; !ok {
+			d.newItems = append(d.newItems, it.Name)
+		}
+		for _, e := range it.Extras {
+			extras[e] = true
+			permitted[combination{item: it.Name, extra: e}] = true
+		}
+		for _, g := range it.Groups {
+			offered[itemGroup{it.Name, g}] = true
+		}
+	}
+	for it := range sm.items {
+		if !onMenu[it] {
+			d.missingItems = append(d.missingItems, it)
+		}
+	}
+	for e := range extras {
+		if _, ok := sm.extras[e]; !ok {
+			d.newExtras = append(d.newExtras, e)
+		}
+	}
+	combined := make(map[string]bool)
+	for _, c := range sm.combinations {
+		if !extras[c.extra] && !combined[c.extra] {
+			d.missingExtras = append(d.missingExtras, c.extra)
+		}
+		combined[c.extra] = true
+	}
+
+	stored := make(map[combination]bool)
+	for _, c := range sm.combinations {
+		key := combination{item: c.item, extra: c.extra}
+		if !permitted[key] || stored[key] {
+			d.removedCombinations = append(d.removedCombinations, c)
+		}
+		stored[key] = true
+	}
+	for c := range permitted {
+		if !stored[c] {
+			d.newCombinations = append(d.newCombinations, c)
+		}
+	}
+
+	storedGroups := make(map[itemGroup]bool)
+	for _, g := range sm.groups {
+		if !offered[g] {
+			d.removedGroups = append(d.removedGroups, g)
+		}
+		storedGroups[g] = true
+	}
+	for g := range offered {
+		if !storedGroups[g] {
+			d.newGroups = append(d.newGroups, g)
+		}
+	}
+
+	sort.Strings(d.newItems)
+	sort.Strings(d.missingItems)
+	sort.Strings(d.newExtras)
+	sort.Strings(d.missingExtras)
+	sort.Slice(d.newCombinations, func(i, j int) bool {
+		return d.newCombinations[i].String() < d.newCombinations[j].String()
+	})
+	sortGroups(d.newGroups)
+	sortGroups(d.removedGroups)
+	return d
+}
+
+// apply applies the given diff to the given stored menu of the product with the
+// given ID in a transaction. New item values have no description or image,
+// since Chili's menu pages don't have them, so they're left NULL rather than
+// blank and the item values aren't offered until someone sets them.
+func (ms menuService) apply(d menuDiff, sm storedMenu, pid string) error {
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting menu sync transaction: %v", err)
+	}
+	for _, it := range d.newItems {
+		q := `
+			INSERT INTO item_values (product_id, item_value, description, image_path)
+			VALUES (?, ?, NULL, NULL)`
+		sm.items[it], err = insertValue(tx, q, pid, it)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting item value: %v", err)
+		}
+	}
+	for _, e := range d.newExtras {
+		q := "INSERT INTO extra_values (extra_value) VALUES (?)"
+		sm.extras[e], err = insertValue(tx, q, e)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting extra value: %v", err)
+		}
+	}
+	for _, c := range d.newCombinations {
+		q := "INSERT INTO item_extra_combinations (item_value_id, extra_value_id) VALUES (?, ?)"
+		_, err = tx.Exec(q, sm.items[c.item], sm.extras[c.extra])
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting item extra combination: %v", err)
+		}
+	}
+	for _, c := range d.removedCombinations {
+		q := "DELETE FROM item_extra_combinations WHERE combination_id = ?"
+		_, err = tx.Exec(q, c.id)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("deleting item extra combination: %v", err)
+		}
+	}
+	for _, g := range d.newGroups {
+		q := "INSERT INTO item_value_groups (item_value_id, group_index) VALUES (?, ?)"
+		_, err = tx.Exec(q, sm.items[g.item], g.group)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting item group: %v", err)
+		}
+	}
+	for _, g := range d.removedGroups {
+		q := "DELETE FROM item_value_groups WHERE item_value_id = ? AND group_index = ?"
+		_, err = tx.Exec(q, sm.items[g.item], g.group)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("deleting item group: %v", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commiting menu sync transaction: %v", err)
+	}
+	return nil
+}
+
+// sortGroups sorts the given item groups by item and then by group.
+func sortGroups(gs []itemGroup) {
+	sort.Slice(gs, func(i, j int) bool {
+		if gs[i].item != gs[j].item {
+			return gs[i].item < gs[j].item
+		}
+		return gs[i].group < gs[j].group
+	})
+}
+
+// insertValue runs the given insertion query with the given arguments in the
+// given transaction and returns the inserted row's ID.
+func insertValue(tx *sql.Tx, q string, args ...interface{}) (int, error) {
+	res, err := tx.Exec(q, args...)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), nil
+}
+
+// A menuItemDiff is the difference between the options on a menu item's Chili's
+// page and the menu item's stored modifier values. Modifier values that are no
+// longer on the menu are only reported, since existing orders refer to them.
+type menuItemDiff struct {
+	item             string
+	newItem          bool
+	newModifiers     []string
+	missingModifiers []string
+}
+
+// empty reports whether the stored menu item is the same as the Chili's one.
+func (d menuItemDiff) empty() bool {
+	return !d.newItem && len(d.newModifiers) == 0 && len(d.missingModifiers) == 0
+}
+
+// String returns a report of the diff with one change per line.
+func (d menuItemDiff) String() string {
+	if d.empty() {
+		return "menu item is up to date"
+	}
+	var b strings.Builder
+	if d.newItem {
+		fmt.Fprintf(&b, "+ menu item %s (Chili's doesn't describe menu items, so it isn't offered until its description and image_path are set)\n", d.item)
+	}
+	for _, m := range d.newModifiers {
+		fmt.Fprintf(&b, "+ modifier value %s\n", m)
+	}
+	for _, m := range d.missingModifiers {
+		fmt.Fprintf(&b, "! modifier value %s isn't on the menu\n", m)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// syncMenuItem diffs the stored modifier values of the menu item with the given
+// name, whose Chili's page is at the given path, against the options on that
+// page at the Chili's location with the given restaurant ID and returns the
+// diff. If apply is true, the menu item is inserted if it's new and new
+// modifier values are inserted.
+func (ms menuService) syncMenuItem(ctx context.Context, lid, name, path string, apply bool) (menuItemDiff, error) {
+	var d menuItemDiff
+	sess, err := ms.cc.StartSession(ctx)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu item: %v", err)
+	}
+	err = sess.SetLocationByID(ctx, lid)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu item: %v", err)
+	}
+	opts, err := sess.MenuOptions(ctx, path)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu item: %v", err)
+	}
+	miid, mods, err := ms.storedModifiers(name)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu item: %v", err)
+	}
+	d = diffMenuItem(name, opts, miid, mods)
+	if !apply || d.empty() {
+		return d, nil
+	}
+	err = ms.applyMenuItem(d, miid, path)
+	if err != nil {
+		return d, fmt.Errorf("syncing menu item: %v", err)
+	}
+	return d, nil
+}
+
+// storedModifiers returns the ID of the menu item with the given name and its
+// stored modifier values mapped to their IDs. The ID is zero if the menu item
+// isn't stored.
+func (ms menuService) storedModifiers(name string) (int, map[string]int, error) {
+	mods := make(map[string]int)
+	var miid int
+	q := "SELECT menu_item_id FROM menu_items WHERE menu_item = ?"
+	err := ms.db.QueryRow(q, name).Scan(&miid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, mods, nil
+	}
+	if err != nil {
+		return 0, nil, fmt.Errorf("finding menu item: %v", err)
+	}
+	q = "SELECT modifier_value_id, modifier_value FROM modifier_values WHERE menu_item_id = ?"
+	err = ms.scanValues(mods, q, miid)
+	if err != nil {
+		return 0, nil, fmt.Errorf("finding modifier values: %v", err)
+	}
+	return miid, mods, nil
+}
+
+// diffMenuItem returns the difference between the given options on the Chili's
+// page of the menu item with the given name and its stored modifier values. The
+// menu item is new if the given ID is zero.
+func diffMenuItem(name string, opts map[string]string, miid int, mods map[string]int) menuItemDiff {
+	d := menuItemDiff{item: name, newItem: miid == 0}
+	for o := range opts {
+		if _, ok := mods[o]; !ok {
+			d.newModifiers = append(d.newModifiers, o)
+		}
+	}
+	for m := range mods {
+		if _, ok := opts[m]; !ok {
+			d.missingModifiers = append(d.missingModifiers, m)
+		}
+	}
+	sort.Strings(d.newModifiers)
+	sort.Strings(d.missingModifiers)
+	return d
+}
+
+// applyMenuItem applies the given diff to the menu item with the given ID, whose
+// Chili's page is at the given path, in a transaction. A new menu item has no
+// description or image, like new item values.
+func (ms menuService) applyMenuItem(d menuItemDiff, miid int, path string) error {
+	tx, err := ms.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting menu item sync transaction: %v", err)
+	}
+	if d.newItem {
+		q := `
+			INSERT INTO menu_items (menu_item, menu_path, description, image_path)
+			VALUES (?, ?, NULL, NULL)`
+		miid, err = insertValue(tx, q, d.item, path)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting menu item: %v", err)
+		}
+	}
+	for _, m := range d.newModifiers {
+		q := "INSERT INTO modifier_values (menu_item_id, modifier_value) VALUES (?, ?)"
+		_, err = insertValue(tx, q, miid, m)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting modifier value: %v", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commiting menu item sync transaction: %v", err)
+	}
+	return nil
+}
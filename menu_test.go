@@ -0,0 +1,155 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cnnrmnn/godipper/chilis"
+)
+
+var diffMenuTests = []struct {
+	name string
+	menu chilis.Menu
+	sm   storedMenu
+	want menuDiff
+}{
+	{
+		name: "up to date",
+		menu: chilis.Menu{Items: []chilis.MenuItem{{Name: "Fried Pickles", Extras: []string{"Ranch Dressing"}}}},
+		sm: storedMenu{
+			items:        map[string]int{"Fried Pickles": 1},
+			extras:       map[string]int{"Ranch Dressing": 1},
+			combinations: []combination{{1, "Fried Pickles", "Ranch Dressing"}},
+		},
+	},
+	{
+		name: "new item and extra",
+		menu: chilis.Menu{Items: []chilis.MenuItem{
+			{Name: "Fried Pickles", Extras: []string{"Ranch Dressing"}},
+			{Name: "Southwestern Eggrolls", Extras: []string{"Avocado-Ranch Dressing"}},
+		}},
+		sm: storedMenu{
+			items:        map[string]int{"Fried Pickles": 1},
+			extras:       map[string]int{"Ranch Dressing": 1},
+			combinations: []combination{{1, "Fried Pickles", "Ranch Dressing"}},
+		},
+		want: menuDiff{
+			newItems:        []string{"Southwestern Eggrolls"},
+			newExtras:       []string{"Avocado-Ranch Dressing"},
+			newCombinations: []combination{{0, "Southwestern Eggrolls", "Avocado-Ranch Dressing"}},
+		},
+	},
+	{
+		name: "added combinations",
+		menu: chilis.Menu{Items: []chilis.MenuItem{
+			{Name: "Fried Pickles", Extras: []string{"Bleu Cheese Dressing", "Ranch Dressing"}},
+			{Name: "Southwestern Eggrolls", Extras: []string{"Ranch Dressing"}},
+		}},
+		sm: storedMenu{
+			items:        map[string]int{"Fried Pickles": 1, "Southwestern Eggrolls": 2},
+			extras:       map[string]int{"Bleu Cheese Dressing": 1, "Ranch Dressing": 2},
+			combinations: []combination{{1, "Fried Pickles", "Ranch Dressing"}},
+		},
+		want: menuDiff{
+			newCombinations: []combination{
+				{0, "Fried Pickles", "Bleu Cheese Dressing"},
+				{0, "Southwestern Eggrolls", "Ranch Dressing"},
+			},
+		},
+	},
+	{
+		name: "removed combinations",
+		menu: chilis.Menu{Items: []chilis.MenuItem{
+			{Name: "Fried Pickles", Extras: []string{"Ranch Dressing"}},
+			{Name: "Southwestern Eggrolls"},
+		}},
+		sm: storedMenu{
+			items:  map[string]int{"Fried Pickles": 1, "Southwestern Eggrolls": 2},
+			extras: map[string]int{"Bleu Cheese Dressing": 1, "Ranch Dressing": 2},
+			combinations: []combination{
+				{1, "Fried Pickles", "Bleu Cheese Dressing"},
+				{2, "Fried Pickles", "Ranch Dressing"},
+				{3, "Southwestern Eggrolls", "Ranch Dressing"},
+				{4, "Fried Pickles", "Ranch Dressing"},
+			},
+		},
+		want: menuDiff{
+			missingExtras: []string{"Bleu Cheese Dressing"},
+			removedCombinations: []combination{
+				{1, "Fried Pickles", "Bleu Cheese Dressing"},
+				{3, "Southwestern Eggrolls", "Ranch Dressing"},
+				{4, "Fried Pickles", "Ranch Dressing"},
+			},
+		},
+	},
+	{
+		name: "changed item groups",
+		menu: chilis.Menu{Items: []chilis.MenuItem{
+			{Name: "Fried Pickles", Groups: []int{0, 1, 2}},
+			{Name: "Southwestern Eggrolls", Groups: []int{0}},
+		}},
+		sm: storedMenu{
+			items:  map[string]int{"Fried Pickles": 1, "Southwestern Eggrolls": 2},
+			extras: map[string]int{},
+			groups: []itemGroup{
+				{"Fried Pickles", 0},
+				{"Southwestern Eggrolls", 0},
+				{"Southwestern Eggrolls", 1},
+			},
+		},
+		want: menuDiff{
+			newGroups:     []itemGroup{{"Fried Pickles", 1}, {"Fried Pickles", 2}},
+			removedGroups: []itemGroup{{"Southwestern Eggrolls", 1}},
+		},
+	},
+	{
+		name: "missing item",
+		menu: chilis.Menu{Items: []chilis.MenuItem{{Name: "Fried Pickles"}}},
+		sm: storedMenu{
+			items:  map[string]int{"Fried Pickles": 1, "Big Mouth® Bites": 2},
+			extras: map[string]int{},
+		},
+		want: menuDiff{missingItems: []string{"Big Mouth® Bites"}},
+	},
+}
+
+func TestDiffMenu(t *testing.T) {
+	for _, test := range diffMenuTests {
+		d := diffMenu(test.menu, test.sm)
+		if !reflect.DeepEqual(d, test.want) {
+			t.Errorf("%s: diff = %+v, want %+v", test.name, d, test.want)
+		}
+		if d.empty() != reflect.DeepEqual(test.want, menuDiff{}) {
+			t.Errorf("%s: empty = %t", test.name, d.empty())
+		}
+	}
+}
+
+func TestDiffMenuItem(t *testing.T) {
+	opts := map[string]string{"Bacon": "285726122", "Cheese": "285726135"}
+	tests := []struct {
+		name string
+		miid int
+		mods map[string]int
+		want menuItemDiff
+	}{
+		{
+			"new menu item", 0, map[string]int{},
+			menuItemDiff{item: "Loaded Mashed Potatoes", newItem: true, newModifiers: []string{"Bacon", "Cheese"}},
+		},
+		{
+			"up to date", 3, map[string]int{"Bacon": 11, "Cheese": 12},
+			menuItemDiff{item: "Loaded Mashed Potatoes"},
+		},
+		{
+			"changed modifiers", 3, map[string]int{"Bacon": 11, "Chives": 13},
+			menuItemDiff{item: "Loaded Mashed Potatoes", newModifiers: []string{"Cheese"}, missingModifiers: []string{"Chives"}},
+		},
+	}
+	for _, test := range tests {
+		d := diffMenuItem("Loaded Mashed Potatoes", opts, test.miid, test.mods)
+		if !reflect.DeepEqual(d, test.want) {
+			t.Errorf("%s: diff = %+v, want %+v", test.name, d, test.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+ALTER TABLE orders
+DROP COLUMN location_zip;
+
+ALTER TABLE orders
+DROP COLUMN location_state;
+
+ALTER TABLE orders
+DROP COLUMN location_city;
+
+ALTER TABLE orders
+DROP COLUMN location_street;
+
+ALTER TABLE orders
+DROP COLUMN location_phone;
+
+ALTER TABLE orders
+DROP COLUMN location_id;
+
+ALTER TABLE orders
+RENAME COLUMN location_name TO location;
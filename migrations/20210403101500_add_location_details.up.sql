@@ -0,0 +1,20 @@
+ALTER TABLE orders
+RENAME COLUMN location TO location_name;
+
+ALTER TABLE orders
+ADD location_id VARCHAR(12);
+
+ALTER TABLE orders
+ADD location_phone CHAR(14);
+
+ALTER TABLE orders
+ADD location_street VARCHAR(50);
+
+ALTER TABLE orders
+ADD location_city VARCHAR(25);
+
+ALTER TABLE orders
+ADD location_state CHAR(2);
+
+ALTER TABLE orders
+ADD location_zip CHAR(5);
@@ -0,0 +1,2 @@
+ALTER TABLE orders
+ADD deliver_at VARCHAR(14);
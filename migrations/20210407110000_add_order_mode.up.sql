@@ -0,0 +1,2 @@
+ALTER TABLE orders
+ADD order_mode ENUM('delivery', 'pickup') NOT NULL DEFAULT 'delivery';
@@ -0,0 +1,2 @@
+ALTER TABLE orders
+ADD session_state TEXT;
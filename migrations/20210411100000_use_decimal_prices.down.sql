@@ -0,0 +1,11 @@
+ALTER TABLE orders
+MODIFY subtotal FLOAT;
+
+ALTER TABLE orders
+MODIFY tax FLOAT;
+
+ALTER TABLE orders
+MODIFY delivery_fee FLOAT;
+
+ALTER TABLE orders
+MODIFY service_fee FLOAT;
@@ -0,0 +1,11 @@
+ALTER TABLE orders
+MODIFY subtotal DECIMAL(10,2);
+
+ALTER TABLE orders
+MODIFY tax DECIMAL(10,2);
+
+ALTER TABLE orders
+MODIFY delivery_fee DECIMAL(10,2);
+
+ALTER TABLE orders
+MODIFY service_fee DECIMAL(10,2);
@@ -0,0 +1,9 @@
+ALTER TABLE item_values
+DROP INDEX uq_item_value,
+ADD CONSTRAINT item_value UNIQUE (item_value);
+
+ALTER TABLE item_values
+DROP COLUMN product_id;
+
+ALTER TABLE triple_dippers
+DROP COLUMN product_id;
@@ -0,0 +1,9 @@
+ALTER TABLE triple_dippers
+ADD product_id VARCHAR(50) NOT NULL DEFAULT 'triple-dipper';
+
+ALTER TABLE item_values
+ADD product_id VARCHAR(50) NOT NULL DEFAULT 'triple-dipper';
+
+ALTER TABLE item_values
+DROP INDEX item_value,
+ADD CONSTRAINT uq_item_value UNIQUE (product_id, item_value);
@@ -0,0 +1,7 @@
+DROP TABLE order_item_modifiers;
+
+DROP TABLE order_items;
+
+DROP TABLE modifier_values;
+
+DROP TABLE menu_items;
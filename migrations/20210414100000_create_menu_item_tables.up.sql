@@ -0,0 +1,40 @@
+CREATE TABLE menu_items (
+    menu_item_id SMALLINT UNSIGNED AUTO_INCREMENT,
+    menu_item VARCHAR(50) UNIQUE NOT NULL,
+    description VARCHAR(100) NOT NULL DEFAULT '',
+    menu_path VARCHAR(100) NOT NULL,
+    image_path VARCHAR(100) NOT NULL DEFAULT '',
+    CONSTRAINT pk_menu_item PRIMARY KEY (menu_item_id)
+);
+
+CREATE TABLE modifier_values (
+    modifier_value_id SMALLINT UNSIGNED AUTO_INCREMENT,
+    menu_item_id SMALLINT UNSIGNED NOT NULL,
+    modifier_value VARCHAR(50) NOT NULL,
+    CONSTRAINT pk_modifier_value PRIMARY KEY (modifier_value_id),
+    CONSTRAINT uq_modifier_value UNIQUE (menu_item_id, modifier_value),
+    CONSTRAINT fk_modifier_menu_item FOREIGN KEY (menu_item_id)
+    REFERENCES menu_items (menu_item_id)
+);
+
+CREATE TABLE order_items (
+    order_item_id SMALLINT UNSIGNED AUTO_INCREMENT,
+    order_id SMALLINT UNSIGNED NOT NULL,
+    menu_item_id SMALLINT UNSIGNED NOT NULL,
+    quantity TINYINT UNSIGNED NOT NULL DEFAULT 1,
+    CONSTRAINT pk_order_item PRIMARY KEY (order_item_id),
+    CONSTRAINT fk_oi_order FOREIGN KEY (order_id)
+    REFERENCES orders (order_id),
+    CONSTRAINT fk_oi_menu_item FOREIGN KEY (menu_item_id)
+    REFERENCES menu_items (menu_item_id)
+);
+
+CREATE TABLE order_item_modifiers (
+    order_item_id SMALLINT UNSIGNED NOT NULL,
+    modifier_value_id SMALLINT UNSIGNED NOT NULL,
+    CONSTRAINT pk_order_item_modifier PRIMARY KEY (order_item_id, modifier_value_id),
+    CONSTRAINT fk_oim_order_item FOREIGN KEY (order_item_id)
+    REFERENCES order_items (order_item_id),
+    CONSTRAINT fk_oim_modifier_value FOREIGN KEY (modifier_value_id)
+    REFERENCES modifier_values (modifier_value_id)
+);
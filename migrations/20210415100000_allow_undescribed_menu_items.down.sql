@@ -0,0 +1,6 @@
+UPDATE menu_items
+SET description = COALESCE(description, ''), image_path = COALESCE(image_path, '');
+
+ALTER TABLE menu_items
+MODIFY description VARCHAR(100) NOT NULL DEFAULT '',
+MODIFY image_path VARCHAR(100) NOT NULL DEFAULT '';
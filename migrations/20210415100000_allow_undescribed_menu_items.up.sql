@@ -0,0 +1,3 @@
+ALTER TABLE menu_items
+MODIFY description VARCHAR(100),
+MODIFY image_path VARCHAR(100);
@@ -0,0 +1,14 @@
+CREATE TABLE item_value_groups (
+    item_value_id SMALLINT UNSIGNED NOT NULL,
+    group_index TINYINT UNSIGNED NOT NULL,
+    CONSTRAINT pk_item_value_group PRIMARY KEY (item_value_id, group_index),
+    CONSTRAINT fk_group_item_value FOREIGN KEY (item_value_id)
+    REFERENCES item_values (item_value_id)
+);
+
+INSERT INTO item_value_groups
+    (item_value_id, group_index)
+SELECT iv.item_value_id, g.group_index
+FROM item_values iv
+    CROSS JOIN (SELECT 0 AS group_index UNION SELECT 1 UNION SELECT 2) g
+WHERE iv.product_id = 'triple-dipper';
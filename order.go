@@ -9,34 +9,48 @@ import (
 
 	"github.com/cnnrmnn/godipper/chilis"
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
 )
 
-// An Order is an order of triple dippers.
+// An Order is an order of triple dippers and other menu items.
 type Order struct {
-	ID            int             `json:"id"`
-	UserID        int             `json:"userId"`
-	SessionID     string          `json:"sessionId"`
-	Location      string          `json:"location"`
-	Address       *Address        `json:"addressId"`
-	TripleDippers []*TripleDipper `json:"tripleDippers"`
-	Completed     bool            `json:"completed"`
-	Subtotal      float32         `json:"subtotal"`
-	Tax           float32         `json:"tax"`
-	DeliveryFee   float32         `json:"deliveryFee"`
-	ServiceFee    float32         `json:"serviceFee"`
-	DeliveryTime  time.Time       `json:"deliveryTime"`
+	ID        int    `json:"id"`
+	UserID    int    `json:"userId"`
+	SessionID string `json:"sessionId"`
+	// SessionState is the exported Chili's session that the order was
+	// checked out with.
+	SessionState  []byte           `json:"-"`
+	Location      *chilis.Location `json:"location"`
+	Address       *Address         `json:"addressId"`
+	TripleDippers []*TripleDipper  `json:"tripleDippers"`
+	Items         []*OrderItem     `json:"items"`
+	Completed     bool             `json:"completed"`
+	Subtotal      chilis.Money     `json:"subtotal"`
+	Tax           chilis.Money     `json:"tax"`
+	DeliveryFee   chilis.Money     `json:"deliveryFee"`
+	ServiceFee    chilis.Money     `json:"serviceFee"`
+	DeliveryTime  time.Time        `json:"deliveryTime"`
+	DeliverAt     string           `json:"deliverAt"`
+	OrderMode     chilis.OrderMode `json:"orderMode"`
+}
+
+// Total returns the sum of the order's subtotal, tax, and fees.
+func (o *Order) Total() chilis.Money {
+	return o.Subtotal + o.Tax + o.DeliveryFee + o.ServiceFee
 }
 
 // orderService implements the order interface. Its methods manage orders.
 type orderService struct {
 	db  *sql.DB
-	as  addressService
-	tds tripleDipperService
-	us  userService
+	cc  *chilis.Client
+	as  address
+	tds tripleDipper
+	ois orderItem
+	us  user
 }
 
-// populate populates the order's list of triple dippers and the order's
-// address (if applicable).
+// populate populates the order's lists of triple dippers and items and the
+// order's address (if applicable).
 func (ors orderService) populate(o *Order) error {
 	var err error
 	if o.Address.ID != 0 {
@@ -49,27 +63,68 @@ func (ors orderService) populate(o *Order) error {
 	if err != nil {
 		return fmt.Errorf("getting order triple dippers: %v", err)
 	}
+	o.Items, err = ors.ois.findByOrder(o.ID)
+	if err != nil {
+		return fmt.Errorf("getting order items: %v", err)
+	}
 	return nil
 }
 
-// findByUser retuirns a slice of orders associated with the current user.
-func (ors orderService) findByUser(ctx context.Context) ([]*Order, error) {
-	uid, err := ors.us.idFromSession(ctx)
-	if err != nil {
-		return nil, err
-	}
-	q := `
-		SELECT
+// orderColumns are the columns selected when finding orders in the order that
+// scanOrder expects them.
+const orderColumns = `
 			order_id, user_id, completed,
-			COALESCE(location, ''),
+			COALESCE(location_id, ''),
+			COALESCE(location_name, ''),
+			COALESCE(location_phone, ''),
+			COALESCE(location_street, ''),
+			COALESCE(location_city, ''),
+			COALESCE(location_state, ''),
+			COALESCE(location_zip, ''),
 			COALESCE(address_id, 0),
 			COALESCE(session_id, ''),
+			COALESCE(session_state, ''),
 			COALESCE(subtotal, 0),
 			COALESCE(tax, 0),
 			COALESCE(delivery_fee, 0),
 			COALESCE(service_fee, 0),
 			COALESCE(delivery_time,
-				STR_TO_DATE('1970-01-01 00:00:01', '%Y-%m-%d %H:%i:%s'))
+				STR_TO_DATE('1970-01-01 00:00:01', '%Y-%m-%d %H:%i:%s')),
+			COALESCE(deliver_at, ''),
+			order_mode`
+
+// scanner is implemented by *sql.Row and *sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanOrder scans and returns an order from a row of orderColumns. The order's
+// location is nil unless the order has been placed.
+func scanOrder(sc scanner) (*Order, error) {
+	o := Order{Address: &Address{}}
+	var loc chilis.Location
+	err := sc.Scan(&o.ID, &o.UserID, &o.Completed, &loc.ID, &loc.Name,
+		&loc.Phone, &loc.Address.Street, &loc.Address.City,
+		&loc.Address.State, &loc.Address.Zip, &o.Address.ID, &o.SessionID,
+		&o.SessionState, &o.Subtotal, &o.Tax, &o.DeliveryFee, &o.ServiceFee, &o.DeliveryTime,
+		&o.DeliverAt, &o.OrderMode)
+	if err != nil {
+		return nil, err
+	}
+	if loc.Name != "" {
+		o.Location = &loc
+	}
+	return &o, nil
+}
+
+// findByUser retuirns a slice of orders associated with the current user.
+func (ors orderService) findByUser(ctx context.Context) ([]*Order, error) {
+	uid, err := ors.us.idFromSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q := `
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE completed = TRUE AND user_id = ?`
 	rows, err := ors.db.Query(q, uid)
@@ -79,19 +134,15 @@ func (ors orderService) findByUser(ctx context.Context) ([]*Order, error) {
 	defer rows.Close()
 	var orders []*Order
 	for rows.Next() {
-		o := Order{Address: &Address{}}
-		err := rows.
-			Scan(&o.ID, &o.UserID, &o.Completed, &o.Location, &o.Address.ID,
-				&o.SessionID, &o.Subtotal, &o.Tax, &o.DeliveryFee,
-				&o.ServiceFee, &o.DeliveryTime)
+		o, err := scanOrder(rows)
 		if err != nil {
 			return nil, fmt.Errorf("reading order: %v", err)
 		}
-		err = ors.populate(&o)
+		err = ors.populate(o)
 		if err != nil {
 			return nil, fmt.Errorf("reading order: %v", err)
 		}
-		orders = append(orders, &o)
+		orders = append(orders, o)
 	}
 	err = rows.Err()
 	if err != nil {
@@ -123,33 +174,19 @@ func (ors orderService) create(o *Order) error {
 // current return the current user's current order. If the current user has no
 // current order, it creates an order and returns it.
 func (ors orderService) current(ctx context.Context) (*Order, error) {
-	o := Order{Address: &Address{}}
 	uid, err := ors.us.idFromSession(ctx)
 	if err != nil {
 		return nil, err
 	}
 	q := `
-		SELECT
-			order_id, user_id, completed,
-			COALESCE(location, ''),
-			COALESCE(address_id, 0),
-			COALESCE(session_id, ''),
-			COALESCE(subtotal, 0),
-			COALESCE(tax, 0),
-			COALESCE(delivery_fee, 0),
-			COALESCE(service_fee, 0),
-			COALESCE(delivery_time,
-				STR_TO_DATE('1970-01-01 00:00:01', '%Y-%m-%d %H:%i:%s'))
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE completed = FALSE AND user_id = ?
 		ORDER BY created_at DESC`
-	err = ors.db.QueryRow(q, uid).
-		Scan(&o.ID, &o.UserID, &o.Completed, &o.Location, &o.Address.ID,
-			&o.SessionID, &o.Subtotal, &o.Tax, &o.DeliveryFee,
-			&o.ServiceFee, &o.DeliveryTime)
+	o, err := scanOrder(ors.db.QueryRow(q, uid))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			no := &Order{UserID: uid}
+			no := &Order{UserID: uid, Address: &Address{}, OrderMode: chilis.Delivery}
 			e := ors.create(no)
 			if e != nil {
 				return nil, fmt.Errorf("getting current order: %v", e)
@@ -160,11 +197,11 @@ func (ors orderService) current(ctx context.Context) (*Order, error) {
 	}
 	// This could be expensive for larger orders. Make it possible to turn
 	// off order population when only the ID is needed.
-	err = ors.populate(&o)
+	err = ors.populate(o)
 	if err != nil {
 		return nil, fmt.Errorf("finding current order: %v", err)
 	}
-	return &o, nil
+	return o, nil
 }
 
 // updateOrder updates the mutable fields in the database row corresponsing to
@@ -174,21 +211,46 @@ func (ors orderService) updateOrder(o *Order) error {
 		UPDATE orders
 		SET
 			address_id = ?,
-			location = ?,
+			location_id = ?,
+			location_name = ?,
+			location_phone = ?,
+			location_street = ?,
+			location_city = ?,
+			location_state = ?,
+			location_zip = ?,
 			session_id = ?,
+			session_state = ?,
 			subtotal = ?,
 			tax = ?,
 			delivery_fee = ?,
 			service_fee = ?,
 			delivery_time = ?,
+			deliver_at = ?,
+			order_mode = ?,
 			completed = ?
 		WHERE order_id = ?`
 	stmt, err := ors.db.Prepare(q)
 	if err != nil {
 		return fmt.Errorf("preparing order update query: %v", err)
 	}
-	_, err = stmt.Exec(o.Address.ID, o.Location, o.SessionID, o.Subtotal, o.Tax,
-		o.DeliveryFee, o.ServiceFee, o.DeliveryTime, o.Completed, o.ID)
+	var loc chilis.Location
+	if o.Location != nil {
+		loc = *o.Location
+	}
+	// Pickup orders don't have an address.
+	var aid interface{}
+	if o.Address.ID != 0 {
+		aid = o.Address.ID
+	}
+	// Orders that haven't been checked out don't have a delivery time.
+	var dt interface{}
+	if !o.DeliveryTime.IsZero() {
+		dt = o.DeliveryTime
+	}
+	_, err = stmt.Exec(aid, loc.ID, loc.Name, loc.Phone,
+		loc.Address.Street, loc.Address.City, loc.Address.State,
+		loc.Address.Zip, o.SessionID, o.SessionState, o.Subtotal, o.Tax, o.DeliveryFee,
+		o.ServiceFee, dt, o.DeliverAt, o.OrderMode, o.Completed, o.ID)
 	if err != nil {
 		return fmt.Errorf("executing order update query: %v", err)
 	}
@@ -207,7 +269,11 @@ func (ors orderService) cart(td *TripleDipper, ctx context.Context) error {
 		return err
 	}
 	td.OrderID = o.ID
-	return ors.tds.create(td)
+	err = ors.tds.create(td)
+	if err != nil {
+		return err
+	}
+	return ors.uncheckOut(o)
 }
 
 // uncart creates a triple dipper that belongs to the current user's current
@@ -217,25 +283,162 @@ func (ors orderService) uncart(tdid int, ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	return ors.tds.destroy(tdid, o.ID)
+	err = ors.tds.destroy(tdid, o.ID)
+	if err != nil {
+		return err
+	}
+	return ors.uncheckOut(o)
 }
 
-// checkOut populates the current user's current order with information from
-// Chilis and returns it.
-func (ors orderService) checkOut(ctx context.Context, aid int) (*Order, error) {
+// recart updates the items of a triple dipper that belongs to the current
+// user's current order, which hasn't been placed.
+func (ors orderService) recart(td *TripleDipper, ctx context.Context) error {
+	o, err := ors.current(ctx)
+	if err != nil {
+		return err
+	}
+	err = ors.tds.update(td, o.ID)
+	if err != nil {
+		return err
+	}
+	return ors.uncheckOut(o)
+}
+
+// cartItem creates an order item that belongs to the current user's current
+// order.
+func (ors orderService) cartItem(oi *OrderItem, ctx context.Context) error {
+	o, err := ors.current(ctx)
+	if err != nil {
+		return err
+	}
+	oi.OrderID = o.ID
+	err = ors.ois.create(oi)
+	if err != nil {
+		return err
+	}
+	return ors.uncheckOut(o)
+}
+
+// uncartItem destroys the order item with the given ID that belongs to the
+// current user's current order.
+func (ors orderService) uncartItem(oiid int, ctx context.Context) error {
+	o, err := ors.current(ctx)
+	if err != nil {
+		return err
+	}
+	err = ors.ois.destroy(oiid, o.ID)
+	if err != nil {
+		return err
+	}
+	return ors.uncheckOut(o)
+}
+
+// uncheckOut forgets the order's checkout, if it's been checked out, after its
+// triple dippers or items have changed. The Chili's cart and the order's
+// prices and delivery time no longer match the order, so it has to be checked out again before
+// it's placed rather than paying for what was checked out.
+func (ors orderService) uncheckOut(o *Order) error {
+	if o.SessionID == "" {
+		return nil
+	}
+	o.SessionID = ""
+	o.SessionState = nil
+	o.Subtotal = 0
+	o.Tax = 0
+	o.DeliveryFee = 0
+	o.ServiceFee = 0
+	o.DeliveryTime = time.Time{}
+	o.DeliverAt = ""
+	return ors.updateOrder(o)
+}
+
+// deliverySlots returns the delivery or pickup slots offered for the current
+// user's current order. The order must have been checked out.
+func (ors orderService) deliverySlots(ctx context.Context) ([]chilis.DeliverySlot, error) {
 	o, err := ors.current(ctx)
 	if err != nil {
 		return nil, err
 	}
-	tdrs, err := ors.tds.findByOrder(o.ID)
+	if o.SessionID == "" {
+		return nil, badRequestError{"order", "check out before choosing a delivery time"}
+	}
+	sess, err := ors.session(o)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	var slots []chilis.DeliverySlot
+	if o.OrderMode == chilis.Pickup {
+		slots, err = sess.PickupSlots(ctx)
+	} else {
+		slots, err = sess.DeliverySlots(ctx)
+	}
+	return slots, upstream(err)
+}
+
+// A DeliveryQuote is a quote for delivering an order. Info is the order's
+// prices, including fees, if they were asked for.
+type DeliveryQuote struct {
+	chilis.Quote
+	Info *chilis.OrderInfo
+}
+
+// quote returns a quote for delivering the current user's current order to the
+// current user's address with the given ID. Nothing is submitted to Chili's on
+// the order's behalf. Prices are only found if asked for and the order isn't
+// empty: its triple dippers and items are then added to the cart of a new
+// session, which takes a request each and leaves a cart behind that's never
+// checked out (Chili's expires it with the session).
+func (ors orderService) quote(ctx context.Context, aid int, prices bool) (*DeliveryQuote, error) {
+	o, err := ors.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a, err := ors.as.findByID(aid)
 	if err != nil {
 		return nil, err
 	}
-	if len(tdrs) == 0 {
-		return nil, errors.New("cart is empty")
+	if o.UserID != a.UserID {
+		return nil, notFoundError{"address"}
 	}
 
-	sess, err := chilis.StartSession()
+	sess, err := ors.cc.StartSession(ctx)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	cq, err := sess.Quote(ctx, a.Address)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	q := DeliveryQuote{Quote: cq}
+	if !prices || !q.InRange || len(o.TripleDippers) == 0 && len(o.Items) == 0 {
+		return &q, nil
+	}
+	for _, td := range o.TripleDippers {
+		err = sess.Cart(ctx, td)
+		if err != nil {
+			return nil, upstream(err)
+		}
+	}
+	for _, oi := range o.Items {
+		err = cartItem(ctx, sess, oi)
+		if err != nil {
+			return nil, upstream(err)
+		}
+	}
+	info, err := sess.Prices(ctx)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	q.Info = &info
+	return &q, nil
+}
+
+// checkOut populates the current user's current order with information from
+// Chilis and returns it. If the given location ID is empty, the location
+// nearest to the address is used. If the given delivery slot time is empty,
+// the order is delivered as soon as possible.
+func (ors orderService) checkOut(ctx context.Context, aid int, lid, deliverAt string) (*Order, error) {
+	o, err := ors.current(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -244,63 +447,338 @@ func (ors orderService) checkOut(ctx context.Context, aid int) (*Order, error) {
 		return nil, err
 	}
 	if o.UserID != a.UserID {
-		return nil, errors.New("address does not belong to current user")
+		return nil, notFoundError{"address"}
 	}
-	err = sess.SetLocation(a.Address)
+	sess, err := ors.cartSession(ctx, o, func(sess *chilis.Session) error {
+		if lid == "" {
+			return sess.SetLocation(ctx, a.Address)
+		}
+		return setNearbyLocation(ctx, sess, a.Address, lid)
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	// Tried to do this concurrently but Chili's server couldn't handle
-	// concurrent requests.
-	for _, td := range tdrs {
-		err = sess.Cart(td)
-		if err != nil {
-			return nil, err
-		}
+	u, err := ors.us.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := sess.Checkout(ctx, u.Customer, a.Address, deliverAt)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	o.setInfo(info)
+	o.OrderMode = chilis.Delivery
+	o.Address.ID = aid
+	err = o.setSession(sess)
+	if err != nil {
+		return nil, err
+	}
+	err = ors.updateOrder(o)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// checkOutPickup populates the current user's current order with information
+// from Chili's for pickup at the location with the given ID and returns it. If
+// the given pickup slot time is empty, the order is ready as soon as possible.
+func (ors orderService) checkOutPickup(ctx context.Context, lid, pickupAt string) (*Order, error) {
+	o, err := ors.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sess, err := ors.cartSession(ctx, o, func(sess *chilis.Session) error {
+		return sess.SetLocationByID(ctx, lid)
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	u, err := ors.us.me(ctx)
 	if err != nil {
 		return nil, err
 	}
-	info, err := sess.Checkout(u.Customer, a.Address)
+	info, err := sess.CheckoutPickup(ctx, u.Customer, pickupAt)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	o.setInfo(info)
+	o.OrderMode = chilis.Pickup
+	o.Address = &Address{}
+	err = o.setSession(sess)
+	if err != nil {
+		return nil, err
+	}
+	err = ors.updateOrder(o)
+	if err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// cartSession resumes the order's Chili's session or, if it doesn't have one,
+// starts a new one. It sets the session's location using the given function
+// and makes the session's cart contain exactly the order's triple dippers and
+// items. If the resumed session's cart can't be read, it's abandoned for a new
+// session with an empty cart that the triple dippers and items are added to.
+func (ors orderService) cartSession(ctx context.Context, o *Order, locate func(*chilis.Session) error) (*chilis.Session, error) {
+	tdrs, err := ors.tds.findByOrder(o.ID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := ors.ois.findByOrder(o.ID)
 	if err != nil {
 		return nil, err
 	}
+	if len(tdrs) == 0 && len(items) == 0 {
+		return nil, badRequestError{"cart", "cart is empty"}
+	}
+
+	var sess *chilis.Session
+	if o.SessionID == "" {
+		sess, err = ors.cc.StartSession(ctx)
+	} else {
+		sess, err = ors.session(o)
+	}
+	if err != nil {
+		return nil, upstream(err)
+	}
+	err = locate(sess)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	err = reconcile(ctx, sess, tdrs, items)
+	if errors.Is(err, chilis.ErrCartUnrecognized) {
+		sess, err = ors.cc.StartSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+		err = locate(sess)
+		if err != nil {
+			return nil, err
+		}
+		err = cartAll(ctx, sess, tdrs, items)
+	}
+	if err != nil {
+		return nil, upstream(err)
+	}
+	return sess, nil
+}
+
+// reconcile makes the session's cart contain exactly the given triple dippers
+// and order items. Lines in the cart that aren't among them are removed, and
+// triple dippers and order items that aren't in the cart are added to it, so
+// checking an order out again doesn't add them to the cart twice. Identical
+// triple dippers are counted: a line is only kept if there are as many
+// identical triple dippers left to match as its quantity, so the cart ends up
+// with exactly as many of each as the order has. If the cart can't be read,
+// it's left as is and the error wraps chilis.ErrCartUnrecognized.
+func reconcile(ctx context.Context, sess *chilis.Session, tdrs []*TripleDipper, items []*OrderItem) error {
+	lines, err := sess.CartContents(ctx)
+	if err != nil {
+		return err
+	}
+	missing := append([]*TripleDipper(nil), tdrs...)
+	missingItems := append([]*OrderItem(nil), items...)
+lines:
+	for _, l := range lines {
+		for i, oi := range missingItems {
+			if l.MatchesItem(oi.Name, oi.Quantity, oi.ModifierValues()) {
+				missingItems = append(missingItems[:i], missingItems[i+1:]...)
+				continue lines
+			}
+		}
+		var matched []int
+		for i, td := range missing {
+			if len(matched) < l.Quantity && l.Matches(td) {
+				matched = append(matched, i)
+			}
+		}
+		if len(matched) == l.Quantity {
+			for j := len(matched) - 1; j >= 0; j-- {
+				i := matched[j]
+				missing = append(missing[:i], missing[i+1:]...)
+			}
+			continue
+		}
+		err = sess.RemoveLine(ctx, l.ID)
+		if err != nil {
+			return err
+		}
+	}
+	return cartAll(ctx, sess, missing, missingItems)
+}
+
+// cartAll adds the given triple dippers and order items to the session's cart.
+func cartAll(ctx context.Context, sess *chilis.Session, tdrs []*TripleDipper, items []*OrderItem) error {
+	// Tried to do this concurrently but Chili's server couldn't handle
+	// concurrent requests. A Session serializes its requests anyway.
+	for _, td := range tdrs {
+		err := sess.Cart(ctx, td)
+		if err != nil {
+			return err
+		}
+	}
+	for _, oi := range items {
+		err := cartItem(ctx, sess, oi)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cartItem adds the order item to the session's cart. Its modifiers must be on
+// the menu of the session's location.
+func cartItem(ctx context.Context, sess *chilis.Session, oi *OrderItem) error {
+	opts, err := sess.MenuOptions(ctx, oi.MenuPath)
+	if err != nil {
+		return err
+	}
+	var ids []string
+	for _, m := range oi.Modifiers {
+		id, ok := opts[m.Value]
+		if !ok {
+			return badRequestError{"modifiers", fmt.Sprintf("%s with %s isn't on this location's menu", oi.Name, m.Value)}
+		}
+		ids = append(ids, id)
+	}
+	return sess.CartItem(ctx, oi.MenuPath, ids, oi.Quantity)
+}
+
+// setInfo sets the order's prices and times to those in the given info.
+func (o *Order) setInfo(info chilis.OrderInfo) {
 	o.Subtotal = info.Subtotal
 	o.Tax = info.Tax
 	o.DeliveryFee = info.DeliveryFee
 	o.ServiceFee = info.ServiceFee
 	o.DeliveryTime = info.DeliveryTime
-	o.Address.ID = aid
+	o.DeliverAt = ""
+	if !info.Slot.ASAP {
+		o.DeliverAt = info.Slot.Time
+	}
+}
+
+// setSession sets the order's session to the given Chili's session.
+func (o *Order) setSession(sess *chilis.Session) error {
+	state, err := sess.Export()
+	if err != nil {
+		return err
+	}
 	o.SessionID = sess.ID
+	o.SessionState = state
+	return nil
+}
+
+// session resumes the Chili's session that the order was checked out with.
+// Orders checked out before session state was stored only have a session ID.
+func (ors orderService) session(o *Order) (*chilis.Session, error) {
+	if len(o.SessionState) == 0 {
+		return ors.cc.NewSession(o.SessionID)
+	}
+	return ors.cc.RestoreSession(o.SessionState)
+}
+
+// recheckOut checks the order out again at the location with the given ID in
+// a new Chili's session and returns the session. It's used when the session
+// that the order was checked out with has expired. If the order's total has
+// changed, the order is updated and an error is returned so that the user
+// isn't charged an amount that they haven't seen. If the order's delivery or
+// pickup time is no longer offered, the order's session is cleared and an
+// error is returned so that the user can check out again and choose another
+// time instead of having it chosen for them.
+func (ors orderService) recheckOut(ctx context.Context, o *Order, lid string) (*chilis.Session, error) {
+	// The expired session can't be resumed, so make cartSession start a new
+	// one.
+	o.SessionID = ""
+	o.SessionState = nil
+	sess, err := ors.cartSession(ctx, o, func(sess *chilis.Session) error {
+		if lid != "" {
+			return sess.SetLocationByID(ctx, lid)
+		}
+		if o.OrderMode == chilis.Pickup {
+			return badRequestError{"location", "pickup location is unknown, check out again"}
+		}
+		return sess.SetLocation(ctx, o.Address.Address)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := ors.us.me(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var info chilis.OrderInfo
+	if o.OrderMode == chilis.Pickup {
+		info, err = sess.CheckoutPickup(ctx, u.Customer, o.DeliverAt)
+	} else {
+		info, err = sess.Checkout(ctx, u.Customer, o.Address.Address, o.DeliverAt)
+	}
+	if o.DeliverAt != "" && slotUnavailable(err) {
+		err = ors.updateOrder(o)
+		if err != nil {
+			return nil, err
+		}
+		return nil, badRequestError{"deliverAt", "the order's delivery or pickup time is no longer available, check out again to choose another"}
+	}
+	if err != nil {
+		return nil, upstream(err)
+	}
+	changed := info.Subtotal != o.Subtotal || info.Tax != o.Tax ||
+		info.DeliveryFee != o.DeliveryFee || info.ServiceFee != o.ServiceFee
+	o.setInfo(info)
+	err = o.setSession(sess)
+	if err != nil {
+		return nil, err
+	}
 	err = ors.updateOrder(o)
 	if err != nil {
 		return nil, err
 	}
-	return o, nil
+	if changed {
+		return nil, errTotalChanged
+	}
+	return sess, nil
+}
+
+// slotUnavailable reports whether the error is Chili's refusing a delivery or
+// pickup time.
+func slotUnavailable(err error) bool {
+	var bre chilis.BadRequestError
+	return errors.As(err, &bre) &&
+		(bre.Field == "delivery time" || bre.Field == "pickup time")
 }
 
-// place places and returns the current user's current order.
+// place places and returns the current user's current order. If the order's
+// Chili's session has expired, the order is checked out again first.
 func (ors orderService) place(ctx context.Context, pm *chilis.PaymentMethod) (*Order, error) {
 	o, err := ors.current(ctx)
 	if err != nil {
 		return nil, err
 	}
 	if o.SessionID == "" {
-		return nil, errors.New("check out before placing an order")
+		return nil, badRequestError{"order", "check out before placing an order"}
 	}
-	sess, err := chilis.NewSession(o.SessionID)
+	sess, err := ors.session(o)
 	if err != nil {
-		return nil, err
+		return nil, upstream(err)
+	}
+	loc, err := sess.Order(ctx, pm, o.OrderMode)
+	if errors.Is(err, chilis.ErrSessionExpired) {
+		sess, err = ors.recheckOut(ctx, o, sess.LocationID)
+		if err != nil {
+			return nil, err
+		}
+		loc, err = sess.Order(ctx, pm, o.OrderMode)
 	}
-	loc, err := sess.Order(pm)
 	if err != nil {
-		return nil, err
+		return nil, upstream(err)
 	}
 
-	o.Location = loc
+	o.Location = &loc
 	o.Completed = true
 	err = ors.updateOrder(o)
 	if err != nil {
@@ -324,36 +802,197 @@ var orderType = graphql.NewObject(
 				Type: graphql.NewNonNull(graphql.String),
 			},
 			"location": &graphql.Field{
-				Type: graphql.String,
+				Type: locationType,
 			},
 			"tripleDippers": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(tripleDipperType))),
 			},
+			"items": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(orderItemType))),
+			},
 			"address": &graphql.Field{
-				Type: graphql.NewNonNull(addressType),
+				Type: addressType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					o := p.Source.(*Order)
+					if o.Address == nil || o.Address.ID == 0 {
+						return nil, nil
+					}
+					return o.Address, nil
+				},
+			},
+			"orderMode": &graphql.Field{
+				Type: graphql.NewNonNull(orderModeType),
 			},
 			"completed": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Boolean),
 			},
 			"subtotal": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Float),
+				Type: graphql.NewNonNull(moneyType),
 			},
 			"tax": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Float),
+				Type: graphql.NewNonNull(moneyType),
 			},
 			"deliveryFee": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Float),
+				Type: graphql.NewNonNull(moneyType),
 			},
 			"serviceFee": &graphql.Field{
-				Type: graphql.NewNonNull(graphql.Float),
+				Type: graphql.NewNonNull(moneyType),
+			},
+			"total": &graphql.Field{
+				Type: graphql.NewNonNull(moneyType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*Order).Total(), nil
+				},
 			},
 			"deliveryTime": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.String),
 			},
+			"deliverAt": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					o := p.Source.(*Order)
+					if o.DeliverAt == "" {
+						return nil, nil
+					}
+					return o.DeliverAt, nil
+				},
+			},
+		},
+	},
+)
+
+// orderModeType is the GraphQL type for chilis.OrderMode.
+var orderModeType = graphql.NewEnum(
+	graphql.EnumConfig{
+		Name: "OrderMode",
+		Values: graphql.EnumValueConfigMap{
+			"DELIVERY": &graphql.EnumValueConfig{Value: chilis.Delivery},
+			"PICKUP":   &graphql.EnumValueConfig{Value: chilis.Pickup},
+		},
+	},
+)
+
+// moneyType is the GraphQL type for chilis.Money. Amounts are serialized as
+// decimal strings of dollars, like "13.19", so that clients don't have to deal
+// with floating point rounding.
+var moneyType = graphql.NewScalar(
+	graphql.ScalarConfig{
+		Name:        "Money",
+		Description: "An amount of US dollars as a decimal string, like \"13.19\".",
+		Serialize: func(value interface{}) interface{} {
+			switch m := value.(type) {
+			case chilis.Money:
+				return m.Decimal()
+			case *chilis.Money:
+				return m.Decimal()
+			}
+			return nil
+		},
+		ParseValue: func(value interface{}) interface{} {
+			s, ok := value.(string)
+			if !ok {
+				return nil
+			}
+			m, err := chilis.ParseMoney(s)
+			if err != nil {
+				return nil
+			}
+			return m
+		},
+		ParseLiteral: func(valueAST ast.Value) interface{} {
+			var raw string
+			switch v := valueAST.(type) {
+			case *ast.StringValue:
+				raw = v.Value
+			case *ast.FloatValue:
+				raw = v.Value
+			case *ast.IntValue:
+				raw = v.Value
+			default:
+				return nil
+			}
+			m, err := chilis.ParseMoney(raw)
+			if err != nil {
+				return nil
+			}
+			return m
+		},
+	},
+)
+
+// deliverySlotType is the GraphQL type for chilis.DeliverySlot.
+var deliverySlotType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "DeliverySlot",
+		Fields: graphql.Fields{
+			"date": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"time": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"label": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"asap": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
 		},
 	},
 )
 
+// deliveryQuoteType is the GraphQL type for DeliveryQuote. Prices are null
+// unless they were asked for, and if the order is empty or the address is out
+// of range.
+var deliveryQuoteType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "DeliveryQuote",
+		Fields: graphql.Fields{
+			"location": &graphql.Field{
+				Type: graphql.NewNonNull(nearbyLocationType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*DeliveryQuote).Location, nil
+				},
+			},
+			"inRange": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(*DeliveryQuote).InRange, nil
+				},
+			},
+			"deliveryTime": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					q := p.Source.(*DeliveryQuote)
+					if !q.InRange {
+						return nil, nil
+					}
+					return q.DeliveryTime, nil
+				},
+			},
+			"subtotal":    quotePrice(func(info *chilis.OrderInfo) chilis.Money { return info.Subtotal }),
+			"tax":         quotePrice(func(info *chilis.OrderInfo) chilis.Money { return info.Tax }),
+			"deliveryFee": quotePrice(func(info *chilis.OrderInfo) chilis.Money { return info.DeliveryFee }),
+			"serviceFee":  quotePrice(func(info *chilis.OrderInfo) chilis.Money { return info.ServiceFee }),
+		},
+	},
+)
+
+// quotePrice returns a GraphQL field of deliveryQuoteType that resolves to the
+// price returned by the given function, or null if the quote has no prices.
+func quotePrice(price func(*chilis.OrderInfo) chilis.Money) *graphql.Field {
+	return &graphql.Field{
+		Type: moneyType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			q := p.Source.(*DeliveryQuote)
+			if q.Info == nil {
+				return nil, nil
+			}
+			return price(q.Info), nil
+		},
+	}
+}
+
 // orders returns a GraphQL query field that resolves to the current user's
 // completed orders.
 func orders(svc *service) *graphql.Field {
@@ -376,31 +1015,62 @@ func currentOrder(svc *service) *graphql.Field {
 	}
 }
 
+// deliverySlots returns a GraphQL query field that resolves to the delivery
+// slots offered for the current user's current order.
+func deliverySlots(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(deliverySlotType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return svc.order.deliverySlots(p.Context)
+		},
+	}
+}
+
+// deliveryQuote returns a GraphQL query field that resolves to a quote for
+// delivering the current user's current order to the given address. Prices are
+// only found if asked for, since finding them fills a Chili's cart.
+func deliveryQuote(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(deliveryQuoteType),
+		Args: graphql.FieldConfigArgument{
+			"addressId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"prices": &graphql.ArgumentConfig{
+				Type:         graphql.Boolean,
+				DefaultValue: false,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			prices, _ := p.Args["prices"].(bool)
+			return svc.order.quote(p.Context, p.Args["addressId"].(int), prices)
+		},
+	}
+}
+
 // addToCart returns a GraphQL mutation field that adds the given triple dipper
-// to the current user's current order and resolves to that triple dipper.
+// to the current user's current order and resolves to that triple dipper. The
+// product ID is the triple dipper's by default.
 func addToCart(svc *service) *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.NewNonNull(tripleDipperType),
 		Args: graphql.FieldConfigArgument{
+			"productId": &graphql.ArgumentConfig{
+				Type:         graphql.String,
+				DefaultValue: chilis.TripleDipperProduct.ID,
+			},
 			"items": &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemInputType))),
 			},
 		},
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			var items []*Item
-			for _, item := range p.Args["items"].([]interface{}) {
-				iin := item.(map[string]interface{})
-				ivid := iin["valueId"].(int)
-				var extras []*Extra
-				for _, ein := range iin["extras"].([]interface{}) {
-					evid := ein.(int)
-					extras = append(extras, &Extra{ValueID: evid})
-				}
-				items = append(items, &Item{ValueID: ivid, Extras: extras})
+			pid := p.Args["productId"].(string)
+			if _, ok := chilis.FindProduct(pid); !ok {
+				return nil, badRequestError{"product", "product is unknown"}
 			}
-
 			td := &TripleDipper{
-				Items: items,
+				ProductID: pid,
+				Items:     itemsArg(p.Args["items"]),
 			}
 			err := svc.order.cart(td, p.Context)
 			if err != nil {
@@ -411,6 +1081,34 @@ func addToCart(svc *service) *graphql.Field {
 	}
 }
 
+// updateCartItem returns a GraphQL mutation field that replaces the items of the
+// given triple dipper in the current user's current order and resolves to that
+// triple dipper.
+func updateCartItem(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(tripleDipperType),
+		Args: graphql.FieldConfigArgument{
+			"tripleDipperId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"items": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemInputType))),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			td := &TripleDipper{
+				ID:    p.Args["tripleDipperId"].(int),
+				Items: itemsArg(p.Args["items"]),
+			}
+			err := svc.order.recart(td, p.Context)
+			if err != nil {
+				return nil, err
+			}
+			return td, nil
+		},
+	}
+}
+
 // removeFromCart returns a GraphQL mutation field that removes the given
 // triple dipper from the current user's current order and resolves to a
 // boolean value reflecting the outcome of the operation.
@@ -433,7 +1131,8 @@ func removeFromCart(svc *service) *graphql.Field {
 }
 
 // checkOut returns a GraphQL mutation field that populates the current user's
-// current order with information from Chili's given an address ID.
+// current order with information from Chili's given an address ID and,
+// optionally, a location ID and a delivery slot time.
 func checkOut(svc *service) *graphql.Field {
 	return &graphql.Field{
 		Type: graphql.NewNonNull(orderType),
@@ -441,9 +1140,38 @@ func checkOut(svc *service) *graphql.Field {
 			"addressId": &graphql.ArgumentConfig{
 				Type: graphql.NewNonNull(graphql.Int),
 			},
+			"locationId": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+			"deliverAt": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			lid, _ := p.Args["locationId"].(string)
+			at, _ := p.Args["deliverAt"].(string)
+			return svc.order.checkOut(p.Context, p.Args["addressId"].(int), lid, at)
+		},
+	}
+}
+
+// checkOutPickup returns a GraphQL mutation field that populates the current
+// user's current order with information from Chili's for pickup given a
+// location ID and, optionally, a pickup slot time.
+func checkOutPickup(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(orderType),
+		Args: graphql.FieldConfigArgument{
+			"locationId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"pickupAt": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
 		},
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
-			return svc.order.checkOut(p.Context, p.Args["addressId"].(int))
+			at, _ := p.Args["pickupAt"].(string)
+			return svc.order.checkOutPickup(p.Context, p.Args["locationId"].(string), at)
 		},
 	}
 }
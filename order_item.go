@@ -0,0 +1,456 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+// A MenuItem is a Chili's menu item, like a side, a drink, or a dessert, that's
+// ordered on its own rather than as part of a triple dipper.
+type MenuItem struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ImagePath   string `json:"imagePath"`
+	// MenuPath is the path of the menu item's page on the Chili's website.
+	MenuPath  string      `json:"-"`
+	Modifiers []*Modifier `json:"modifiers"`
+}
+
+// A Modifier is an option that can be chosen for a menu item, like a size or a
+// flavor.
+type Modifier struct {
+	ID    int    `json:"id"`
+	Value string `json:"value"`
+}
+
+// An OrderItem is a quantity of a menu item with the chosen modifiers that
+// belongs to an order.
+type OrderItem struct {
+	ID         int         `json:"id"`
+	OrderID    int         `json:"orderId"`
+	MenuItemID int         `json:"menuItemId"`
+	Name       string      `json:"name"`
+	MenuPath   string      `json:"-"`
+	Quantity   int         `json:"quantity"`
+	Modifiers  []*Modifier `json:"modifiers"`
+}
+
+// ModifierValues returns a slice of the order item's modifiers' values.
+func (oi OrderItem) ModifierValues() []string {
+	var vals []string
+	for _, m := range oi.Modifiers {
+		vals = append(vals, m.Value)
+	}
+	return vals
+}
+
+// orderItemService implements the orderItem interface. Its methods manage
+// menu items and the order items made of them.
+type orderItemService struct {
+	db *sql.DB
+}
+
+// menuItems returns a slice of all available menu items. Menu items that
+// haven't been described since being synced from the menu aren't available.
+func (ois orderItemService) menuItems() ([]*MenuItem, error) {
+	q := `
+		SELECT menu_item_id, menu_item, description, image_path, menu_path
+		FROM menu_items
+		WHERE description IS NOT NULL AND image_path IS NOT NULL
+		ORDER BY menu_item`
+	rows, err := ois.db.Query(q)
+	if err != nil {
+		return nil, fmt.Errorf("finding menu items: %v", err)
+	}
+	defer rows.Close()
+	var mis []*MenuItem
+	for rows.Next() {
+		var mi MenuItem
+		err = rows.Scan(&mi.ID, &mi.Name, &mi.Description, &mi.ImagePath, &mi.MenuPath)
+		if err != nil {
+			return nil, fmt.Errorf("scanning menu item: %v", err)
+		}
+		mis = append(mis, &mi)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("reading menu items: %v", err)
+	}
+	for _, mi := range mis {
+		mi.Modifiers, err = ois.modifiers(mi.ID)
+		if err != nil {
+			return nil, fmt.Errorf("finding menu item modifiers: %v", err)
+		}
+	}
+	return mis, nil
+}
+
+// findMenuItem returns the available menu item with the given ID or an error if
+// no available menu item has the given ID.
+func (ois orderItemService) findMenuItem(id int) (*MenuItem, error) {
+	mi := MenuItem{ID: id}
+	q := `
+		SELECT menu_item, description, image_path, menu_path
+		FROM menu_items
+		WHERE menu_item_id = ?
+			AND description IS NOT NULL AND image_path IS NOT NULL`
+	err := ois.db.QueryRow(q, id).Scan(&mi.Name, &mi.Description, &mi.ImagePath, &mi.MenuPath)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError{"menu item"}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("finding menu item by ID: %v", err)
+	}
+	mi.Modifiers, err = ois.modifiers(id)
+	if err != nil {
+		return nil, fmt.Errorf("finding menu item modifiers: %v", err)
+	}
+	return &mi, nil
+}
+
+// modifiers returns a slice of the modifiers that can be chosen for the menu
+// item with the given ID.
+func (ois orderItemService) modifiers(miid int) ([]*Modifier, error) {
+	q := `
+		SELECT modifier_value_id, modifier_value
+		FROM modifier_values
+		WHERE menu_item_id = ?
+		ORDER BY modifier_value_id`
+	return ois.scanModifiers(q, miid)
+}
+
+// scanModifiers runs the given query, which selects modifier value IDs and
+// values, with the given arguments and returns the modifiers.
+func (ois orderItemService) scanModifiers(q string, args ...interface{}) ([]*Modifier, error) {
+	rows, err := ois.db.Query(q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ms := []*Modifier{}
+	for rows.Next() {
+		var m Modifier
+		err = rows.Scan(&m.ID, &m.Value)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, &m)
+	}
+	return ms, rows.Err()
+}
+
+// findByOrder returns a slice of order items that belong to the order with the
+// given ID.
+func (ois orderItemService) findByOrder(oid int) ([]*OrderItem, error) {
+	q := `
+		SELECT oi.order_item_id, oi.order_id, oi.menu_item_id, mi.menu_item,
+			mi.menu_path, oi.quantity
+		FROM order_items oi INNER JOIN menu_items mi
+		ON oi.menu_item_id = mi.menu_item_id
+		WHERE oi.order_id = ?`
+	rows, err := ois.db.Query(q, oid)
+	if err != nil {
+		return nil, fmt.Errorf("finding order items by order ID: %v", err)
+	}
+	defer rows.Close()
+	var items []*OrderItem
+	for rows.Next() {
+		var oi OrderItem
+		err = rows.Scan(&oi.ID, &oi.OrderID, &oi.MenuItemID, &oi.Name, &oi.MenuPath, &oi.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("reading order item: %v", err)
+		}
+		items = append(items, &oi)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("reading order items: %v", err)
+	}
+	for _, oi := range items {
+		q := `
+			SELECT mv.modifier_value_id, mv.modifier_value
+			FROM order_item_modifiers oim INNER JOIN modifier_values mv
+			ON oim.modifier_value_id = mv.modifier_value_id
+			WHERE oim.order_item_id = ?
+			ORDER BY mv.modifier_value_id`
+		oi.Modifiers, err = ois.scanModifiers(q, oi.ID)
+		if err != nil {
+			return nil, fmt.Errorf("finding order item modifiers: %v", err)
+		}
+	}
+	return items, nil
+}
+
+// maxQuantity is the largest quantity of an order item, which is the most that
+// its column can store.
+const maxQuantity = 255
+
+// create creates the given order item. Its quantity must be between 1 and
+// maxQuantity and its modifiers must be ones that can be chosen for its menu
+// item.
+func (ois orderItemService) create(oi *OrderItem) error {
+	if oi.Quantity < 1 {
+		return badRequestError{"quantity", "quantity must be at least 1"}
+	}
+	if oi.Quantity > maxQuantity {
+		return badRequestError{"quantity", fmt.Sprintf("quantity must be at most %d", maxQuantity)}
+	}
+	mi, err := ois.findMenuItem(oi.MenuItemID)
+	var nfe notFoundError
+	if errors.As(err, &nfe) {
+		return badRequestError{"menu item", "menu item is unknown"}
+	}
+	if err != nil {
+		return fmt.Errorf("creating order item: %v", err)
+	}
+	offered := make(map[int]*Modifier)
+	for _, m := range mi.Modifiers {
+		offered[m.ID] = m
+	}
+	chosen := make(map[int]bool)
+	for _, m := range oi.Modifiers {
+		om, ok := offered[m.ID]
+		if !ok {
+			return badRequestError{"modifiers", "modifier isn't offered with the menu item"}
+		}
+		if chosen[m.ID] {
+			return badRequestError{"modifiers", "modifier is chosen more than once"}
+		}
+		chosen[m.ID] = true
+		m.Value = om.Value
+	}
+
+	tx, err := ois.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting order item insertion transaction: %v", err)
+	}
+	q := "INSERT INTO order_items (order_id, menu_item_id, quantity) VALUES (?, ?, ?)"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing order item insertion query: %v", err)
+	}
+	res, err := stmt.Exec(oi.OrderID, oi.MenuItemID, oi.Quantity)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("executing order item insertion query: %v", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("getting order item ID: %v", err)
+	}
+	oi.ID = int(id)
+	q = "INSERT INTO order_item_modifiers (order_item_id, modifier_value_id) VALUES (?, ?)"
+	stmt, err = tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing order item modifier insertion query: %v", err)
+	}
+	for _, m := range oi.Modifiers {
+		_, err = stmt.Exec(oi.ID, m.ID)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("inserting order item modifier: %v", err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commiting order item insertion transaction: %v", err)
+	}
+	oi.Name = mi.Name
+	oi.MenuPath = mi.MenuPath
+	if oi.Modifiers == nil {
+		oi.Modifiers = []*Modifier{}
+	}
+	return nil
+}
+
+// destroy destroys the order item with the given ID that belongs to the order
+// with the given ID or returns an error if none exist.
+func (ois orderItemService) destroy(id int, oid int) error {
+	var ioid int
+	q := "SELECT order_id FROM order_items WHERE order_item_id = ?"
+	err := ois.db.QueryRow(q, id).Scan(&ioid)
+	if errors.Is(err, sql.ErrNoRows) {
+		return notFoundError{"order item"}
+	}
+	if err != nil {
+		return fmt.Errorf("finding order item to be destroyed: %v", err)
+	}
+	if ioid != oid {
+		return notFoundError{"order item"}
+	}
+	tx, err := ois.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting order item deletion transaction: %v", err)
+	}
+	q = "DELETE FROM order_item_modifiers WHERE order_item_id = ?"
+	stmt, err := tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing order item modifier deletion query: %v", err)
+	}
+	_, err = stmt.Exec(id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("executing order item modifier deletion query: %v", err)
+	}
+	q = "DELETE FROM order_items WHERE order_item_id = ?"
+	stmt, err = tx.Prepare(q)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("preparing order item deletion query: %v", err)
+	}
+	_, err = stmt.Exec(id)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("executing order item deletion query: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commiting order item deletion transaction: %v", err)
+	}
+	return nil
+}
+
+// modifierType is the GraphQL type for Modifier.
+var modifierType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Modifier",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"value": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+		},
+	},
+)
+
+// menuItemType is the GraphQL type for MenuItem.
+var menuItemType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "MenuItem",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"description": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"imagePath": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"modifiers": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(modifierType))),
+			},
+		},
+	},
+)
+
+// orderItemType is the GraphQL type for OrderItem.
+var orderItemType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "OrderItem",
+		Fields: graphql.Fields{
+			"id": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"orderId": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"menuItemId": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"name": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
+			"quantity": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"modifiers": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(modifierType))),
+			},
+		},
+	},
+)
+
+// menuItems returns a GraphQL query field that resolves to a list of available
+// menu items.
+func menuItems(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(menuItemType))),
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return svc.orderItem.menuItems()
+		},
+	}
+}
+
+// addMenuItemToCart returns a GraphQL mutation field that adds the given
+// quantity of the given menu item with the given modifiers to the current
+// user's current order and resolves to the order item.
+func addMenuItemToCart(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(orderItemType),
+		Args: graphql.FieldConfigArgument{
+			"menuItemId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"quantity": &graphql.ArgumentConfig{
+				Type:         graphql.Int,
+				DefaultValue: 1,
+			},
+			"modifiers": &graphql.ArgumentConfig{
+				Type:         graphql.NewList(graphql.NewNonNull(graphql.Int)),
+				DefaultValue: []interface{}{},
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			var ms []*Modifier
+			mins, _ := p.Args["modifiers"].([]interface{})
+			for _, m := range mins {
+				ms = append(ms, &Modifier{ID: m.(int)})
+			}
+			oi := &OrderItem{
+				MenuItemID: p.Args["menuItemId"].(int),
+				Quantity:   p.Args["quantity"].(int),
+				Modifiers:  ms,
+			}
+			err := svc.order.cartItem(oi, p.Context)
+			if err != nil {
+				return nil, err
+			}
+			return oi, nil
+		},
+	}
+}
+
+// removeMenuItemFromCart returns a GraphQL mutation field that removes the
+// given order item from the current user's current order and resolves to a
+// boolean value reflecting the outcome of the operation.
+func removeMenuItemFromCart(svc *service) *graphql.Field {
+	return &graphql.Field{
+		Type: graphql.NewNonNull(graphql.Boolean),
+		Args: graphql.FieldConfigArgument{
+			"orderItemId": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			err := svc.order.uncartItem(p.Args["orderItemId"].(int), p.Context)
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		},
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newTestOrderItemService returns an orderItemService whose database is
+// mocked.
+func newTestOrderItemService(t *testing.T) (orderItemService, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return orderItemService{db: db}, mock
+}
+
+// expectMenuItem expects the loaded mashed potatoes menu item, which can have
+// bacon or cheese, to be found.
+func expectMenuItem(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`FROM menu_items\s+WHERE menu_item_id = \?`).
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"menu_item", "description", "image_path", "menu_path"}).
+			AddRow("Loaded Mashed Potatoes", "Mashed potatoes", "/assets/menu/3.png", "/menu/sides/loaded-mashed-potatoes"))
+	mock.ExpectQuery("FROM modifier_values").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows([]string{"modifier_value_id", "modifier_value"}).
+			AddRow(11, "Bacon").
+			AddRow(12, "Cheese"))
+}
+
+func TestOrderItemServiceCreateInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		oi    OrderItem
+		known bool
+		field string
+	}{
+		{"no quantity", OrderItem{MenuItemID: 3}, false, "quantity"},
+		{"too many", OrderItem{MenuItemID: 3, Quantity: maxQuantity + 1}, false, "quantity"},
+		{"unknown menu item", OrderItem{MenuItemID: 3, Quantity: 1}, false, "menu item"},
+		{
+			"modifier not offered",
+			OrderItem{MenuItemID: 3, Quantity: 1, Modifiers: []*Modifier{{ID: 13}}},
+			true, "modifiers",
+		},
+		{
+			"duplicate modifier",
+			OrderItem{MenuItemID: 3, Quantity: 1, Modifiers: []*Modifier{{ID: 11}, {ID: 11}}},
+			true, "modifiers",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ois, mock := newTestOrderItemService(t)
+			if test.known {
+				expectMenuItem(mock)
+			} else if test.oi.Quantity >= 1 && test.oi.Quantity <= maxQuantity {
+				mock.ExpectQuery("FROM menu_items").
+					WillReturnRows(sqlmock.NewRows([]string{"menu_item", "description", "image_path", "menu_path"}))
+			}
+			err := ois.create(&test.oi)
+			var bre badRequestError
+			if !errors.As(err, &bre) || bre.field != test.field {
+				t.Errorf("err = %v, want invalid %s", err, test.field)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestOrderItemServiceCreate(t *testing.T) {
+	ois, mock := newTestOrderItemService(t)
+	expectMenuItem(mock)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO order_items").
+		ExpectExec().
+		WithArgs(1, 3, 2).
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectPrepare("INSERT INTO order_item_modifiers").
+		ExpectExec().
+		WithArgs(7, 12).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	oi := OrderItem{OrderID: 1, MenuItemID: 3, Quantity: 2, Modifiers: []*Modifier{{ID: 12}}}
+	if err := ois.create(&oi); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if oi.ID != 7 {
+		t.Errorf("ID = %d, want 7", oi.ID)
+	}
+	if oi.Name != "Loaded Mashed Potatoes" || oi.MenuPath != "/menu/sides/loaded-mashed-potatoes" {
+		t.Errorf("name, menu path = %s, %s, want the menu item's", oi.Name, oi.MenuPath)
+	}
+	if oi.Modifiers[0].Value != "Cheese" {
+		t.Errorf("modifier value = %s, want Cheese", oi.Modifiers[0].Value)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderItemServiceCreateRollback(t *testing.T) {
+	ois, mock := newTestOrderItemService(t)
+	expectMenuItem(mock)
+	mock.ExpectBegin()
+	mock.ExpectPrepare("INSERT INTO order_items").
+		ExpectExec().
+		WillReturnResult(sqlmock.NewResult(7, 1))
+	mock.ExpectPrepare("INSERT INTO order_item_modifiers").
+		ExpectExec().
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	oi := OrderItem{OrderID: 1, MenuItemID: 3, Quantity: 1, Modifiers: []*Modifier{{ID: 11}}}
+	if err := ois.create(&oi); err == nil {
+		t.Error("create succeeded without inserting its modifier")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderItemServiceDestroy(t *testing.T) {
+	ois, mock := newTestOrderItemService(t)
+	q := `SELECT order_id FROM order_items WHERE order_item_id = \?`
+	mock.ExpectQuery(q).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+	mock.ExpectQuery(q).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow(2))
+	mock.ExpectQuery(q).
+		WithArgs(7).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectPrepare("DELETE FROM order_item_modifiers").
+		ExpectExec().
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectPrepare("DELETE FROM order_items").
+		ExpectExec().
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	var nfe notFoundError
+	if err := ois.destroy(7, 1); !errors.As(err, &nfe) {
+		t.Errorf("destroy nonexistent order item: err = %v, want not found", err)
+	}
+	if err := ois.destroy(7, 1); !errors.As(err, &nfe) {
+		t.Errorf("destroy another order's item: err = %v, want not found", err)
+	}
+	if err := ois.destroy(7, 1); err != nil {
+		t.Errorf("destroy: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
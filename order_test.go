@@ -0,0 +1,459 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cnnrmnn/godipper/chilis"
+	"github.com/cnnrmnn/godipper/chilis/chilistest"
+)
+
+func TestOrderServiceCheckOutPlace(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+
+	o := &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery}
+	expectCurrent(mock, o)
+	expectUpdate(mock)
+	o, err := ors.checkOut(ctx, testAddress.ID, "", "")
+	if err != nil {
+		t.Fatalf("checkOut: %v", err)
+	}
+	if o.SessionID == "" || len(o.SessionState) == 0 {
+		t.Fatalf("checkOut didn't store the Chili's session")
+	}
+	if o.Subtotal == 0 {
+		t.Errorf("subtotal = 0, want the checkout page's subtotal")
+	}
+	if o.Address.ID != testAddress.ID {
+		t.Errorf("address ID = %d, want %d", o.Address.ID, testAddress.ID)
+	}
+	sess, ok := srv.Session(o.SessionID)
+	if !ok {
+		t.Fatalf("session %s doesn't exist", o.SessionID)
+	}
+	if len(sess.Cart) != 1 {
+		t.Errorf("cart has %d lines, want 1", len(sess.Cart))
+	}
+
+	expectCurrent(mock, o)
+	expectUpdate(mock)
+	o, err = ors.place(ctx, testPaymentMethod())
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if !o.Completed {
+		t.Errorf("order isn't completed")
+	}
+	if o.Location == nil || o.Location.Name != "Durham 15/501" {
+		t.Errorf("location = %+v, want Durham 15/501", o.Location)
+	}
+	sess, _ = srv.Session(o.SessionID)
+	if got := sess.Payment.Get("cardNumber"); got != "4111-1111-1111-1111" {
+		t.Errorf("card number = %s, want 4111-1111-1111-1111", got)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderServicePlaceNotCheckedOut(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+
+	expectCurrent(mock, &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery})
+	_, err := ors.place(ctx, testPaymentMethod())
+	var bre badRequestError
+	if !errors.As(err, &bre) || bre.field != "order" {
+		t.Errorf("place before checking out: err = %v, want an invalid order", err)
+	}
+}
+
+func TestOrderServicePlaceExpired(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+
+	o := &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery}
+	expectCurrent(mock, o)
+	expectUpdate(mock)
+	o, err := ors.checkOut(ctx, testAddress.ID, "", "")
+	if err != nil {
+		t.Fatalf("checkOut: %v", err)
+	}
+	expired := o.SessionID
+	srv.Expire(expired)
+
+	expectCurrent(mock, o)
+	expectUpdate(mock) // checked out again
+	expectUpdate(mock) // placed
+	o, err = ors.place(ctx, testPaymentMethod())
+	if err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if o.SessionID == expired {
+		t.Fatalf("order was placed in the expired session")
+	}
+	if !o.Completed {
+		t.Errorf("order isn't completed")
+	}
+	sess, ok := srv.Session(o.SessionID)
+	if !ok {
+		t.Fatalf("session %s doesn't exist", o.SessionID)
+	}
+	if len(sess.Cart) != 1 {
+		t.Errorf("cart has %d lines, want 1", len(sess.Cart))
+	}
+	if sess.Payment == nil {
+		t.Errorf("order wasn't paid for in session %s", o.SessionID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderServicePlaceExpiredSlotGone(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+
+	o := &Order{
+		ID:        1,
+		UserID:    testUser.ID,
+		Address:   &Address{ID: testAddress.ID},
+		SessionID: "expired",
+		DeliverAt: "20210101 18:30",
+		OrderMode: chilis.Delivery,
+	}
+	expectCurrent(mock, o)
+	expectUpdate(mock) // session cleared
+	_, err := ors.place(ctx, testPaymentMethod())
+	var bre badRequestError
+	if !errors.As(err, &bre) || bre.field != "deliverAt" {
+		t.Errorf("place with a past delivery time: err = %v, want an invalid deliverAt", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// otherTestTripleDipper returns a new triple dipper that's different from the
+// one that newTestTripleDipper returns.
+func otherTestTripleDipper() *TripleDipper {
+	return &TripleDipper{
+		ID: 2,
+		Items: []*Item{
+			{Value: "Crispy Cheddar Bites"},
+			{Value: "Fried Pickles"},
+			{Value: "Southwestern Eggrolls"},
+		},
+	}
+}
+
+// cartedSession returns a new session at the fixtures' location whose cart
+// contains the given triple dippers.
+func cartedSession(t *testing.T, srv *chilistest.Server, tdrs []*TripleDipper) *chilis.Session {
+	t.Helper()
+	ctx := context.Background()
+	c := &chilis.Client{BaseURL: srv.URL}
+	sess, err := c.StartSession(ctx)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	if err := sess.SetLocation(ctx, testAddress.Address); err != nil {
+		t.Fatalf("SetLocation: %v", err)
+	}
+	for _, td := range tdrs {
+		if err := sess.Cart(ctx, td); err != nil {
+			t.Fatalf("Cart: %v", err)
+		}
+	}
+	return sess
+}
+
+func TestReconcile(t *testing.T) {
+	a, b := newTestTripleDipper(1), otherTestTripleDipper()
+	tests := []struct {
+		name   string
+		carted []*TripleDipper
+		order  []*TripleDipper
+		// kept is the number of lines that are left in the cart rather than
+		// removed and added again.
+		kept int
+	}{
+		{"kept", []*TripleDipper{a}, []*TripleDipper{a}, 1},
+		{"removed", []*TripleDipper{a, b}, []*TripleDipper{a}, 1},
+		{"added", []*TripleDipper{a}, []*TripleDipper{a, b}, 1},
+		{"empty cart", nil, []*TripleDipper{a, b}, 0},
+		{"duplicate lines", []*TripleDipper{a, a}, []*TripleDipper{a}, 1},
+		{"duplicate triple dippers", []*TripleDipper{a}, []*TripleDipper{a, a}, 1},
+		{"duplicates of both", []*TripleDipper{a, a}, []*TripleDipper{a, a}, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			srv := chilistest.NewServer(fixtures)
+			defer srv.Close()
+			sess := cartedSession(t, srv, test.carted)
+			before, _ := srv.Session(sess.ID)
+
+			err := reconcile(ctx, sess, test.order, nil)
+			if err != nil {
+				t.Fatalf("reconcile: %v", err)
+			}
+			lines, err := sess.CartContents(ctx)
+			if err != nil {
+				t.Fatalf("CartContents: %v", err)
+			}
+			for _, td := range []*TripleDipper{a, b} {
+				want := 0
+				for _, otd := range test.order {
+					if otd == td {
+						want++
+					}
+				}
+				got := 0
+				for _, l := range lines {
+					if l.Matches(td) {
+						got++
+					}
+				}
+				if got != want {
+					t.Errorf("%d lines match triple dipper %d, want %d", got, td.ID, want)
+				}
+			}
+			if len(lines) != len(test.order) {
+				t.Errorf("len(lines) = %d, want %d", len(lines), len(test.order))
+			}
+			after, _ := srv.Session(sess.ID)
+			kept := 0
+			for _, bl := range before.Cart {
+				for _, al := range after.Cart {
+					if al.ID == bl.ID {
+						kept++
+					}
+				}
+			}
+			if kept != test.kept {
+				t.Errorf("%d lines kept, want %d", kept, test.kept)
+			}
+		})
+	}
+}
+
+func TestReconcileItems(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	path := "/menu/sides/loaded-mashed-potatoes"
+	srv.MenuItems = map[string]string{path: "Loaded Mashed Potatoes"}
+	oi := &OrderItem{
+		Name:      "Loaded Mashed Potatoes",
+		MenuPath:  path,
+		Quantity:  2,
+		Modifiers: []*Modifier{{Value: "Fried Pickles"}},
+	}
+	sess := cartedSession(t, srv, nil)
+	if err := cartItem(ctx, sess, oi); err != nil {
+		t.Fatalf("cartItem: %v", err)
+	}
+	before, _ := srv.Session(sess.ID)
+
+	// The item that's already in the cart is kept, and the same item in a
+	// different quantity replaces it.
+	if err := reconcile(ctx, sess, nil, []*OrderItem{oi}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	after, _ := srv.Session(sess.ID)
+	if len(after.Cart) != 1 || after.Cart[0].ID != before.Cart[0].ID {
+		t.Errorf("cart = %+v, want line %s kept", after.Cart, before.Cart[0].ID)
+	}
+	more := *oi
+	more.Quantity = 3
+	if err := reconcile(ctx, sess, nil, []*OrderItem{&more}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	after, _ = srv.Session(sess.ID)
+	if len(after.Cart) != 1 || after.Cart[0].Quantity != 3 {
+		t.Errorf("cart = %+v, want one line of 3", after.Cart)
+	}
+
+	if err := reconcile(ctx, sess, nil, nil); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	after, _ = srv.Session(sess.ID)
+	if len(after.Cart) != 0 {
+		t.Errorf("cart = %+v, want it empty", after.Cart)
+	}
+}
+
+func TestReconcileReordered(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	td := newTestTripleDipper(1)
+	sess := cartedSession(t, srv, []*TripleDipper{td})
+
+	// Swapping the first two items puts each in the other's selection group,
+	// so the carted line no longer matches.
+	reordered := newTestTripleDipper(1)
+	reordered.Items[0], reordered.Items[1] = reordered.Items[1], reordered.Items[0]
+	if err := reconcile(ctx, sess, []*TripleDipper{reordered}, nil); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	lines, err := sess.CartContents(ctx)
+	if err != nil {
+		t.Fatalf("CartContents: %v", err)
+	}
+	if len(lines) != 1 || !lines[0].Matches(reordered) {
+		t.Fatalf("lines = %+v, want only the reordered triple dipper", lines)
+	}
+	var names []string
+	for _, sel := range lines[0].Selections {
+		names = append(names, sel.Name)
+	}
+	want := []string{"Big Mouth® Bites", "Awesome Blossom Petals", "Boneless Buffalo Wings"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("selections = %v, want %v", names, want)
+	}
+}
+
+func TestCartSessionUnrecognizedCart(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	a, b := newTestTripleDipper(1), otherTestTripleDipper()
+	tdrs := []*TripleDipper{a}
+	var items []*OrderItem
+	ors, _ := newTestOrderService(t, srv, &tdrs, &items)
+	old := cartedSession(t, srv, []*TripleDipper{a, b})
+	o := &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery}
+	if err := o.setSession(old); err != nil {
+		t.Fatal(err)
+	}
+	// The checkout page has a cart button that says the cart has items but
+	// no line items.
+	srv.Fixtures.Cart = "checkout1.html"
+
+	sess, err := ors.cartSession(ctx, o, func(sess *chilis.Session) error {
+		return sess.SetLocation(ctx, testAddress.Address)
+	})
+	if err != nil {
+		t.Fatalf("cartSession: %v", err)
+	}
+	if sess.ID == old.ID {
+		t.Fatalf("cartSession kept the session whose cart it couldn't read")
+	}
+	got, _ := srv.Session(sess.ID)
+	if len(got.Cart) != 1 {
+		t.Errorf("new cart has %d lines, want 1", len(got.Cart))
+	}
+	before, _ := srv.Session(old.ID)
+	if len(before.Cart) != 2 {
+		t.Errorf("old cart has %d lines, want it left with 2", len(before.Cart))
+	}
+}
+
+func TestOrderServiceQuote(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+	o := &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery}
+
+	expectCurrent(mock, o)
+	q, err := ors.quote(ctx, testAddress.ID, false)
+	if err != nil {
+		t.Fatalf("quote: %v", err)
+	}
+	if !q.InRange {
+		t.Error("address is out of range")
+	}
+	if q.Info != nil {
+		t.Errorf("info = %+v without asking for prices, want nil", q.Info)
+	}
+
+	expectCurrent(mock, o)
+	q, err = ors.quote(ctx, testAddress.ID, true)
+	if err != nil {
+		t.Fatalf("quote: %v", err)
+	}
+	if q.Info == nil {
+		t.Fatal("info = nil after asking for prices")
+	}
+	if q.Info.Subtotal.String() != "$13.19" || q.Info.DeliveryFee.String() != "$3.99" {
+		t.Errorf("subtotal, delivery fee = %s, %s, want $13.19, $3.99", q.Info.Subtotal, q.Info.DeliveryFee)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOrderServiceRecartAfterCheckOut(t *testing.T) {
+	ctx := context.Background()
+	srv := chilistest.NewServer(fixtures)
+	defer srv.Close()
+	tdrs := []*TripleDipper{newTestTripleDipper(1)}
+	var items []*OrderItem
+	ors, mock := newTestOrderService(t, srv, &tdrs, &items)
+
+	o := &Order{ID: 1, UserID: testUser.ID, Address: &Address{}, OrderMode: chilis.Delivery}
+	expectCurrent(mock, o)
+	expectUpdate(mock)
+	o, err := ors.checkOut(ctx, testAddress.ID, "", "")
+	if err != nil {
+		t.Fatalf("checkOut: %v", err)
+	}
+
+	if o.DeliveryTime.IsZero() {
+		t.Fatal("checkOut didn't set the delivery time")
+	}
+
+	// Editing the triple dipper clears the order's session, prices, and
+	// delivery time.
+	expectCurrent(mock, o)
+	a := sqlmock.AnyArg()
+	mock.ExpectPrepare("UPDATE orders").
+		ExpectExec().
+		WithArgs(a, a, a, a, a, a, a, a, "", a,
+			"0.00", "0.00", "0.00", "0.00", nil, "", a, false, o.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	if err := ors.recart(newTestTripleDipper(1), ctx); err != nil {
+		t.Fatalf("recart: %v", err)
+	}
+
+	edited := *o
+	edited.SessionID = ""
+	edited.SessionState = nil
+	edited.Subtotal, edited.Tax, edited.DeliveryFee, edited.ServiceFee = 0, 0, 0, 0
+	edited.DeliveryTime = time.Time{}
+	expectCurrent(mock, &edited)
+	_, err = ors.place(ctx, testPaymentMethod())
+	var bre badRequestError
+	if !errors.As(err, &bre) || bre.field != "order" {
+		t.Errorf("place after editing: err = %v, want an invalid order", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
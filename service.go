@@ -26,20 +26,31 @@ type address interface {
 	create(a *Address, ctx context.Context) error
 }
 
+// location defines the methods that should be implemented by the location
+// service.
+type location interface {
+	nearby(ctx context.Context, aid int) ([]chilis.NearbyLocation, error)
+}
+
 // extra defines the methods that should be implemented by the extra service.
 type extra interface {
 	values(ivid int) ([]*Extra, error)
 	findByItem(iid int) ([]*Extra, error)
+	validate(ivid int, exs []*Extra) error
 	create(e *Extra, tx *sql.Tx) error
 	destroy(iid int, tx *sql.Tx) error
+	destroyByID(id int, tx *sql.Tx) error
 }
 
 // item defines the methods that should be implemented by the item service.
 type item interface {
-	values() ([]*Item, error)
+	values(pid string) ([]*Item, error)
 	findByTripleDipper(tdid int) ([]*Item, error)
+	validate(p chilis.Product, its []*Item) error
 	create(it *Item, tx *sql.Tx) error
+	update(tdid int, old, its []*Item, tx *sql.Tx) error
 	destroy(tdid int, tx *sql.Tx) error
+	destroyByID(id int, tx *sql.Tx) error
 }
 
 // tripleDipper defines the methods that should be implemented by the
@@ -49,6 +60,17 @@ type tripleDipper interface {
 	findByID(id int) (*TripleDipper, error)
 	findByOrder(oid int) ([]*TripleDipper, error)
 	create(td *TripleDipper) error
+	update(td *TripleDipper, oid int) error
+	destroy(id int, oid int) error
+}
+
+// orderItem defines the methods that should be implemented by the orderItem
+// service.
+type orderItem interface {
+	menuItems() ([]*MenuItem, error)
+	findMenuItem(id int) (*MenuItem, error)
+	findByOrder(oid int) ([]*OrderItem, error)
+	create(oi *OrderItem) error
 	destroy(id int, oid int) error
 }
 
@@ -60,8 +82,14 @@ type order interface {
 	create(o *Order) error
 	cart(td *TripleDipper, ctx context.Context) error
 	uncart(tdid int, ctx context.Context) error
+	recart(td *TripleDipper, ctx context.Context) error
+	cartItem(oi *OrderItem, ctx context.Context) error
+	uncartItem(oiid int, ctx context.Context) error
 	updateOrder(o *Order) error
-	checkOut(ctx context.Context, aid int) (*Order, error)
+	deliverySlots(ctx context.Context) ([]chilis.DeliverySlot, error)
+	quote(ctx context.Context, aid int, prices bool) (*DeliveryQuote, error)
+	checkOut(ctx context.Context, aid int, lid, deliverAt string) (*Order, error)
+	checkOutPickup(ctx context.Context, lid, pickupAt string) (*Order, error)
 	place(ctx context.Context, pm *chilis.PaymentMethod) (*Order, error)
 }
 
@@ -70,8 +98,10 @@ type order interface {
 type service struct {
 	user
 	address
+	location
 	extra
 	item
 	tripleDipper
+	orderItem
 	order
 }
@@ -9,11 +9,23 @@ import (
 	"github.com/graphql-go/graphql"
 )
 
-// A TripleDipper is a Chili's Triple Dipper.
+// A TripleDipper is a Chili's Triple Dipper or another Chili's product that's
+// built the same way, which is identified by its product ID.
 type TripleDipper struct {
-	ID      int     `json:"id"`
-	OrderID int     `json:"orderId"`
-	Items   []*Item `json:"items"`
+	ID        int     `json:"id"`
+	OrderID   int     `json:"orderId"`
+	ProductID string  `json:"productId"`
+	Items     []*Item `json:"items"`
+}
+
+// Product returns the Chili's product that the triple dipper is. Triple
+// dippers without a known product ID are Chili's Triple Dippers.
+func (td TripleDipper) Product() chilis.Product {
+	p, ok := chilis.FindProduct(td.ProductID)
+	if !ok {
+		return chilis.TripleDipperProduct
+	}
+	return p
 }
 
 // ItemValues returns a slice of the triple dipper's items (that implement the
@@ -49,8 +61,11 @@ func (tds tripleDipperService) populate(td *TripleDipper) error {
 // triple dipper has the given ID.
 func (tds tripleDipperService) findByID(id int) (*TripleDipper, error) {
 	td := TripleDipper{ID: id}
-	q := "SELECT order_id FROM triple_dippers where triple_dipper_id = ?"
-	err := tds.db.QueryRow(q, id).Scan(&td.OrderID)
+	q := "SELECT order_id, product_id FROM triple_dippers where triple_dipper_id = ?"
+	err := tds.db.QueryRow(q, id).Scan(&td.OrderID, &td.ProductID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError{"triple dipper"}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding triple dipper by ID: %v", err)
 	}
@@ -65,7 +80,7 @@ func (tds tripleDipperService) findByID(id int) (*TripleDipper, error) {
 // the given ID.
 func (tds tripleDipperService) findByOrder(oid int) ([]*TripleDipper, error) {
 	q := `
-		SELECT triple_dipper_id, order_id
+		SELECT triple_dipper_id, order_id, product_id
 		FROM triple_dippers
 		WHERE order_id = ?`
 	rows, err := tds.db.Query(q, oid)
@@ -76,7 +91,7 @@ func (tds tripleDipperService) findByOrder(oid int) ([]*TripleDipper, error) {
 	var tdrs []*TripleDipper
 	for rows.Next() {
 		var td TripleDipper
-		err := rows.Scan(&td.ID, &td.OrderID)
+		err := rows.Scan(&td.ID, &td.OrderID, &td.ProductID)
 		if err != nil {
 			return nil, fmt.Errorf("reading triple dipper: %v", err)
 		}
@@ -93,19 +108,25 @@ func (tds tripleDipperService) findByOrder(oid int) ([]*TripleDipper, error) {
 	return tdrs, nil
 }
 
-// create creates a triple dipper.
+// create creates a triple dipper. Its items are validated first, outside of the
+// transaction, since item and extra values only change when the menu is
+// synced.
 func (tds tripleDipperService) create(td *TripleDipper) error {
+	err := tds.is.validate(td.Product(), td.Items)
+	if err != nil {
+		return fmt.Errorf("creating triple dipper: %w", err)
+	}
 	tx, err := tds.db.Begin()
 	if err != nil {
 		return fmt.Errorf("starting triple dipper insertion transaction: %v", err)
 	}
-	q := "INSERT INTO triple_dippers (order_id) VALUES (?)"
+	q := "INSERT INTO triple_dippers (order_id, product_id) VALUES (?, ?)"
 	stmt, err := tx.Prepare(q)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("preparing triple dipper insertion query: %v", err)
 	}
-	res, err := stmt.Exec(td.OrderID)
+	res, err := stmt.Exec(td.OrderID, td.ProductID)
 	if err != nil {
 		tx.Rollback()
 		return fmt.Errorf("executing triple dipper insertion query: %v", err)
@@ -134,20 +155,61 @@ func (tds tripleDipperService) create(td *TripleDipper) error {
 	return nil
 }
 
+// update replaces the items of the triple dipper with the given triple dipper's
+// ID with the given triple dipper's items in a transaction. Items and extras
+// that haven't changed are kept. It returns an error if the triple dipper
+// doesn't belong to the order with the given ID.
+func (tds tripleDipperService) update(td *TripleDipper, oid int) error {
+	old, err := tds.findByID(td.ID)
+	if err != nil {
+		return fmt.Errorf("finding triple dipper to be updated: %w", err)
+	}
+	if old.OrderID != oid {
+		return notFoundError{"triple dipper"}
+	}
+	td.OrderID = old.OrderID
+	td.ProductID = old.ProductID
+	err = tds.is.validate(td.Product(), td.Items)
+	if err != nil {
+		return fmt.Errorf("updating triple dipper: %w", err)
+	}
+	tx, err := tds.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting triple dipper update transaction: %v", err)
+	}
+	// Rolling back after the transaction is committed does nothing.
+	defer tx.Rollback()
+	err = tds.is.update(td.ID, old.Items, td.Items, tx)
+	if err != nil {
+		return fmt.Errorf("updating triple dipper items: %v", err)
+	}
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commiting triple dipper update transaction: %v", err)
+	}
+	err = tds.populate(td)
+	if err != nil {
+		return fmt.Errorf("updating triple dipper: %v", err)
+	}
+	return nil
+}
+
 // destroy destroys the triple dipper with the given ID or returns an error
 // if none exist.
 func (tds tripleDipperService) destroy(id int, oid int) error {
 	td, err := tds.findByID(id)
 	if err != nil {
-		return fmt.Errorf("finding triple dipper to be destroyed: %v", err)
+		return fmt.Errorf("finding triple dipper to be destroyed: %w", err)
 	}
 	if td.OrderID != oid {
-		return errors.New("triple dipper does not belong to current order")
+		return notFoundError{"triple dipper"}
 	}
 	tx, err := tds.db.Begin()
 	if err != nil {
 		return fmt.Errorf("starting triple dipper deletion transaction: %v", err)
 	}
+	// Rolling back after the transaction is committed does nothing.
+	defer tx.Rollback()
 	err = tds.is.destroy(id, tx)
 	if err != nil {
 		return fmt.Errorf("destroying triple dipper items: %v", err)
@@ -155,12 +217,10 @@ func (tds tripleDipperService) destroy(id int, oid int) error {
 	q := "DELETE FROM triple_dippers WHERE triple_dipper_id = ?"
 	stmt, err := tx.Prepare(q)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("preparing triple dipper deletion query: %v", err)
 	}
 	_, err = stmt.Exec(id)
 	if err != nil {
-		tx.Rollback()
 		return fmt.Errorf("executing triple dipper deletion query: %v", err)
 	}
 	err = tx.Commit()
@@ -181,6 +241,9 @@ var tripleDipperType = graphql.NewObject(
 			"orderId": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.Int),
 			},
+			"productId": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.String),
+			},
 			"items": &graphql.Field{
 				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
 			},
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/cnnrmnn/godipper/chilis"
+)
+
+// fakeItems is an item service whose triple dippers all have the items in old
+// and whose updates and deletions fail with err.
+type fakeItems struct {
+	item
+	old []*Item
+	err error
+}
+
+func (is fakeItems) findByTripleDipper(tdid int) ([]*Item, error) {
+	return is.old, nil
+}
+
+func (is fakeItems) validate(p chilis.Product, its []*Item) error {
+	return nil
+}
+
+func (is fakeItems) update(tdid int, old, its []*Item, tx *sql.Tx) error {
+	return is.err
+}
+
+func (is fakeItems) destroy(tdid int, tx *sql.Tx) error {
+	return is.err
+}
+
+func TestTripleDipperServiceUpdate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("sqlmock.New: %v", err)
+	}
+	defer db.Close()
+	old := newTestTripleDipper(1).Items
+	tds := tripleDipperService{db: db, is: fakeItems{old: old, err: errors.New("connection reset")}}
+	q := `SELECT order_id, product_id FROM triple_dippers where triple_dipper_id = \?`
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"order_id", "product_id"}).
+			AddRow(1, chilis.TripleDipperProduct.ID)
+	}
+	mock.ExpectQuery(q).WithArgs(1).WillReturnRows(rows())
+	mock.ExpectQuery(q).WithArgs(1).WillReturnRows(rows())
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	var nfe notFoundError
+	err = tds.update(&TripleDipper{ID: 1, Items: old}, 2)
+	if !errors.As(err, &nfe) {
+		t.Errorf("update another order's triple dipper: err = %v, want not found", err)
+	}
+	err = tds.update(&TripleDipper{ID: 1, Items: old}, 1)
+	if err == nil {
+		t.Error("update succeeded although its items' update failed")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTripleDipperServiceDestroy(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect func(sqlmock.Sqlmock)
+	}{
+		{
+			"items not destroyed", errors.New("connection reset"),
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectRollback()
+			},
+		},
+		{
+			"triple dipper not destroyed", nil,
+			func(mock sqlmock.Sqlmock) {
+				mock.ExpectPrepare("DELETE FROM triple_dippers").
+					ExpectExec().
+					WithArgs(1).
+					WillReturnError(errors.New("connection reset"))
+				mock.ExpectRollback()
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("sqlmock.New: %v", err)
+			}
+			defer db.Close()
+			tds := tripleDipperService{db: db, is: fakeItems{old: newTestTripleDipper(1).Items, err: test.err}}
+			mock.ExpectQuery(`SELECT order_id, product_id FROM triple_dippers where triple_dipper_id = \?`).
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"order_id", "product_id"}).AddRow(1, chilis.TripleDipperProduct.ID))
+			mock.ExpectBegin()
+			test.expect(mock)
+
+			if err := tds.destroy(1, 1); err == nil {
+				t.Error("destroy succeeded")
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
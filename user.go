@@ -35,6 +35,9 @@ func (us userService) findByID(id int) (*User, error) {
 		WHERE user_id = ?`
 	err := us.db.QueryRow(q, id).
 		Scan(&u.ID, &u.FirstName, &u.LastName, &u.Phone, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError{"user"}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding user by id: %v", err)
 	}
@@ -51,6 +54,9 @@ func (us userService) findByPhone(phone string) (*User, error) {
 		WHERE phone = ?`
 	err := us.db.QueryRow(q, phone).
 		Scan(&u.ID, &u.FirstName, &u.LastName, &u.Phone, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, notFoundError{"user"}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("finding user by phone: %v", err)
 	}
@@ -75,7 +81,7 @@ func (us userService) signUp(u *User, code string, ctx context.Context) error {
 		return fmt.Errorf("couldn't check verification code: %v", err)
 	}
 	if !ok {
-		return errors.New("verification code is invalid")
+		return badRequestError{"verification code", "verification code is invalid"}
 	}
 	q := `INSERT INTO users (first_name, last_name, phone, email)
 				VALUES (?, ?, ?, ?)`
@@ -107,11 +113,11 @@ func (us userService) logIn(phone, code string, ctx context.Context) (*User, err
 		return nil, fmt.Errorf("checking verification code: %v", err)
 	}
 	if !ok {
-		return nil, errors.New("verification code is invalid")
+		return nil, badRequestError{"verification code", "verification code is invalid"}
 	}
 	u, err := us.findByPhone(phone)
 	if err != nil {
-		return nil, fmt.Errorf("getting authenticated user: %v", err)
+		return nil, fmt.Errorf("getting authenticated user: %w", err)
 	}
 	err = createSession(u.ID, us.sm, ctx)
 	if err != nil {
@@ -130,7 +136,7 @@ func (us userService) logOut(ctx context.Context) error {
 func (us userService) idFromSession(ctx context.Context) (int, error) {
 	id := us.sm.GetInt(ctx, "id")
 	if id == 0 {
-		return 0, errors.New("no session found")
+		return 0, errUnauthenticated
 	}
 	return id, nil
 }